@@ -1,28 +1,151 @@
 package main
 
 import (
+	"context"
 	"effectiveMobile/pkg/config"
+	"effectiveMobile/pkg/db"
 	"effectiveMobile/pkg/di"
-	"log"
+	"effectiveMobile/pkg/logger"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	migrateUsage = "usage: app [flags] migrate up|down|status|to N"
+	configUsage  = "usage: app [flags] config print"
 )
 
 // @title People API
 // @version 1.0
-// @description This is a sample server People server.
+// @description This is a sample server People server. Errors are returned as RFC 7807 application/problem+json.
 // @contact.name API Support
 // @contact.email support@example.com
 // @host 0.0.0.0:8001
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description Access token as "Bearer <token>", or the token cookie set by /login
 func main() {
-	cfg, configErr := config.LoadConfig()
+	cfg, args, configErr := config.LoadConfig(os.Args[1:])
+	if errors.Is(configErr, flag.ErrHelp) {
+		return
+	}
 	if configErr != nil {
-		log.Fatal("cannot load config: ", configErr)
+		// Все ошибки настроек сразу, по одной на строку
+		fmt.Fprintf(os.Stderr, "invalid config:\n%s\n", configErr)
+		os.Exit(2)
+	}
+	if err := logger.Setup(cfg.LogFormat, cfg.LogLevel); err != nil {
+		log.Fatal("cannot set up logger: ", err)
+	}
+
+	if len(args) > 0 {
+		var err error
+		switch args[0] {
+		case "migrate":
+			err = migrate(cfg, args[1:])
+		case "config":
+			err = printConfig(cfg, args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q, expected migrate or config", args[0])
+		}
+		if err != nil {
+			log.Fatal(args[0], ": ", err)
+		}
+		return
 	}
 
 	server, diErr := di.InitializeAPI(cfg)
 	if diErr != nil {
 		log.Fatal("cannot start server: ", diErr)
-	} else {
-		server.Start()
 	}
+
+	// SIGINT и SIGTERM запускают плавную остановку
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := server.Start(ctx); err != nil {
+		log.Fatal("server stopped with error: ", err)
+	}
+	log.Info("server stopped")
+}
+
+// migrate Подкоманда управления версиями схемы БД
+func migrate(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	ctx := context.Background()
+	bd, err := db.ConnectToBD(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer bd.Close()
+
+	if err = db.WaitForDB(ctx, bd, cfg.PsqlConnectTimeout); err != nil {
+		return err
+	}
+
+	migrator, err := db.NewMigrator(bd)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			return fmt.Errorf(migrateUsage)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		err = migrator.To(ctx, version)
+	case "status":
+		statuses, statusErr := migrator.Status(ctx)
+		if statusErr != nil {
+			return statusErr
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema version %d\n", version)
+	return nil
+}
+
+// printConfig Подкоманда показывает итоговые настройки без секретов
+func printConfig(cfg config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return fmt.Errorf(configUsage)
+	}
+	return cfg.Print(os.Stdout)
 }
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"effectiveMobile/pkg/domain/people"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestAnonymousRoute calls GET /people opened by ANONYMOUS_ROUTES. A caller
+// without a token sees everyone masked and without tasks, an admin who
+// sends their token is still an admin there, and a bad token is refused
+// instead of falling back to anonymous.
+func TestAnonymousRoute(t *testing.T) {
+	cfg := testConfig()
+	cfg.AnonymousRoutes = "/swagger, /people"
+	now := time.Now().UTC()
+
+	expectPeople := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("SELECT id, name, surname, patronymic, address, passportNumber FROM people").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "address", "passportNumber"}).
+				AddRow(int64(1), "Ivan", "Ivanov", "Ivanovich", "Moscow", "1234 567890").
+				AddRow(int64(2), "Petr", "Petrov", "Petrovich", "Moscow", "4321 098765"))
+		mock.ExpectQuery("FROM task WHERE personId = ANY").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "startTime", "endTime", "totalTime", "personId", "status"}).
+				AddRow(int64(1), "task", "", now, nil, nil, int64(2), "running"))
+	}
+
+	tests := []struct {
+		name     string
+		role     string
+		expect   func(mock sqlmock.Sqlmock)
+		status   int
+		passport string
+		tasks    int
+	}{
+		{
+			name:     "anonymous",
+			expect:   expectPeople,
+			status:   http.StatusOK,
+			passport: people.MaskPassport("4321 098765"),
+			tasks:    0,
+		},
+		{
+			name: "admin",
+			role: "admin",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectSession(mock)
+				mock.ExpectQuery("SELECT id, role, managerId FROM people").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "role", "managerId"}).AddRow(int64(1), "admin", nil))
+				expectPeople(mock)
+			},
+			status:   http.StatusOK,
+			passport: "4321 098765",
+			tasks:    1,
+		},
+		{
+			name:   "bad token",
+			role:   "bad",
+			expect: func(sqlmock.Sqlmock) {},
+			status: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mock, tokens := newTestServerWith(t, cfg)
+			tt.expect(mock)
+
+			request := httptest.NewRequest(http.MethodGet, "/people", nil)
+			switch tt.role {
+			case "":
+			case "bad":
+				request.Header.Set("Authorization", "Bearer not-a-token")
+			default:
+				accessToken, _, err := tokens.Create("1", "session", tt.role)
+				if err != nil {
+					t.Fatal(err)
+				}
+				request.Header.Set("Authorization", "Bearer "+accessToken)
+			}
+			recorder := httptest.NewRecorder()
+			server.engine.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.status {
+				t.Fatalf("GET /people = %d, want %d: %s", recorder.Code, tt.status, recorder.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var result []people.Request
+			if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			if len(result) != 2 {
+				t.Fatalf("got %d people, want 2", len(result))
+			}
+			if result[1].PassportNumber != tt.passport {
+				t.Errorf("passport = %q, want %q", result[1].PassportNumber, tt.passport)
+			}
+			if len(result[1].Tasks) != tt.tasks {
+				t.Errorf("got %d tasks, want %d", len(result[1].Tasks), tt.tasks)
+			}
+		})
+	}
+}
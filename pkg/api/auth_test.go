@@ -0,0 +1,176 @@
+package api
+
+import (
+	"effectiveMobile/pkg/api/handler"
+	"effectiveMobile/pkg/config"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestRevokedFamily sends a live access token whose session was revoked
+// with its family after a refresh token reuse.
+func TestRevokedFamily(t *testing.T) {
+	server, mock, accessToken := newTestServer(t)
+	now := time.Now().UTC()
+	mock.ExpectQuery("FROM session WHERE id").
+		WithArgs("session").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "personId", "familyId", "userAgent", "createdAt", "expiresAt", "rotatedAt", "revokedAt"}).
+			AddRow("session", int64(1), "family", "test", now, now.Add(time.Hour), now, now))
+
+	request := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	recorder := httptest.NewRecorder()
+	server.engine.ServeHTTP(recorder, request)
+
+	var problem handler.Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v: %s", err, recorder.Body.String())
+	}
+	if recorder.Code != http.StatusUnauthorized || problem.Code != "session_revoked" {
+		t.Errorf("got %d %s, want 401 session_revoked", recorder.Code, problem.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestAuthMiddleware sends the access token the ways a client can and the
+// tokens that must be refused.
+func TestAuthMiddleware(t *testing.T) {
+	expired := testConfig()
+	expired.JWTTTL = -time.Minute
+
+	tests := []struct {
+		name   string
+		cfg    config.Config
+		send   func(request *http.Request, accessToken string)
+		expect func(mock sqlmock.Sqlmock)
+		status int
+		code   string
+	}{
+		{
+			name: "header",
+			cfg:  testConfig(),
+			send: func(request *http.Request, accessToken string) {
+				request.Header.Set("Authorization", "Bearer "+accessToken)
+			},
+			expect: expectSessions,
+			status: http.StatusOK,
+		},
+		{
+			name: "cookie",
+			cfg:  testConfig(),
+			send: func(request *http.Request, accessToken string) {
+				request.AddCookie(&http.Cookie{Name: "token", Value: accessToken})
+			},
+			expect: expectSessions,
+			status: http.StatusOK,
+		},
+		{
+			name: "header before cookie",
+			cfg:  testConfig(),
+			send: func(request *http.Request, accessToken string) {
+				request.Header.Set("Authorization", "Bearer not-a-token")
+				request.AddCookie(&http.Cookie{Name: "token", Value: accessToken})
+			},
+			expect: func(sqlmock.Sqlmock) {},
+			status: http.StatusUnauthorized,
+			code:   "unauthorized",
+		},
+		{
+			name:   "no token",
+			cfg:    testConfig(),
+			send:   func(*http.Request, string) {},
+			expect: func(sqlmock.Sqlmock) {},
+			status: http.StatusUnauthorized,
+			code:   "unauthorized",
+		},
+		{
+			name: "expired",
+			cfg:  expired,
+			send: func(request *http.Request, accessToken string) {
+				request.Header.Set("Authorization", "Bearer "+accessToken)
+			},
+			expect: func(sqlmock.Sqlmock) {},
+			status: http.StatusUnauthorized,
+			code:   "unauthorized",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mock, tokens := newTestServerWith(t, tt.cfg)
+			tt.expect(mock)
+			accessToken, _, err := tokens.Create("1", "session", "employee")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+			tt.send(request, accessToken)
+			recorder := httptest.NewRecorder()
+			server.engine.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.status {
+				t.Fatalf("GET /sessions = %d, want %d: %s", recorder.Code, tt.status, recorder.Body.String())
+			}
+			if tt.code != "" {
+				var problem handler.Problem
+				if err = json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil || problem.Code != tt.code {
+					t.Errorf("got problem %q, %v, want %q", problem.Code, err, tt.code)
+				}
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestTokenAfterLogout logs out and sends the same access token again. It
+// has not expired yet, but its session is revoked.
+func TestTokenAfterLogout(t *testing.T) {
+	server, mock, accessToken := newTestServer(t)
+	expectSession(mock)
+	mock.ExpectExec("UPDATE session SET revokedAt").
+		WithArgs("session").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	now := time.Now().UTC()
+	mock.ExpectQuery("FROM session WHERE id").
+		WithArgs("session").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "personId", "familyId", "userAgent", "createdAt", "expiresAt", "rotatedAt", "revokedAt"}).
+			AddRow("session", int64(1), "family", "test", now, now.Add(time.Hour), nil, now))
+
+	for _, step := range []struct {
+		method string
+		target string
+		status int
+	}{
+		{http.MethodPost, "/logout", http.StatusOK},
+		{http.MethodGet, "/sessions", http.StatusUnauthorized},
+	} {
+		request := httptest.NewRequest(step.method, step.target, nil)
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+		recorder := httptest.NewRecorder()
+		server.engine.ServeHTTP(recorder, request)
+		if recorder.Code != step.status {
+			t.Fatalf("%s %s = %d, want %d: %s", step.method, step.target, recorder.Code, step.status, recorder.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// expectSessions answers the session lookup of the access token and the
+// list of active sessions.
+func expectSessions(mock sqlmock.Sqlmock) {
+	expectSession(mock)
+	mock.ExpectQuery("FROM session\\s+WHERE personId").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "personId", "familyId", "userAgent", "createdAt", "expiresAt", "rotatedAt", "revokedAt"}))
+}
@@ -3,36 +3,14 @@ package handler
 import (
 	"effectiveMobile/pkg/db"
 	"effectiveMobile/pkg/domain/people"
+	"effectiveMobile/pkg/logger"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	_ "github.com/golang-jwt/jwt/v4"
-	log "github.com/sirupsen/logrus"
 	"net/http"
-	"strconv"
-	"time"
+	"strings"
 )
 
-var jwtKey = []byte("secret_key")
-
-func createToken(id string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":  id,
-		"exp": time.Now().Add(time.Hour * 72).Unix(),
-	})
-
-	return token.SignedString(jwtKey)
-}
-
-func parseToken(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtKey, nil
-	})
-}
-
 // @Summary Register a new user
 // @Description Register a new user with email and password
 // @Tags User
@@ -40,33 +18,24 @@ func parseToken(tokenString string) (*jwt.Token, error) {
 // @Produce  json
 // @Param registration body people.Registration true "User registration info"
 // @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 409 {string} string "Email already exist"
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /registration [post]
 func (h *Handler) Registration(c *gin.Context) {
 	var acc people.Registration
-	if err := c.BindJSON(&acc); err != nil {
-		c.JSON(400, gin.H{"error bind Registration people": err.Error()})
-		log.Error("error bind Registration people %v", err.Error())
+	if err := c.ShouldBindJSON(&acc); err != nil {
+		c.Error(badRequest(err))
 		return
 	}
 	result, err := h.service.Registration(c.Request.Context(), acc)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrDuplicate.Error():
-			c.JSON(409, "passportNumber already exist")
-			log.Error("Register email failed %v", err.Error())
-			break
-		default:
-			c.JSON(500, gin.H{"error Registration people": err.Error()})
-			log.Error("error service Registration people %v", err.Error())
-		}
+		c.Error(err)
 		return
 	}
 
 	c.JSON(201, result)
-	log.Info("Success registration: %v", result)
+	logger.FromContext(c.Request.Context()).WithField("personId", *result).Info("person registered")
 }
 
 // @Summary Login a user
@@ -75,79 +44,139 @@ func (h *Handler) Registration(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param login body people.Registration true "User login info"
+// @Param includeToken query bool false "Return the access and refresh tokens in the body"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var acc people.Registration
-	if err := c.BindJSON(&acc); err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+	if err := c.ShouldBindJSON(&acc); err != nil {
+		c.Error(badRequest(err))
 		return
 	}
 
 	id, err := h.service.Login(c.Request.Context(), acc)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrNotExist.Error():
-			c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "Account does not exist"})
-			log.Error("Account does not exist")
-			break
-		default:
-			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			log.Error("error service Login error %v", err.Error())
+		if errors.Is(err, db.ErrNotExist) {
+			err = &Error{Status: http.StatusUnauthorized, Code: "invalid_credentials", Message: "Invalid passport number or password", Err: err}
 		}
+		c.Error(err)
 		return
 	}
 
-	token, err := createToken(strconv.FormatInt(id, 10))
+	currSession, refreshToken, err := h.service.CreateSession(c.Request.Context(), id, c.Request.UserAgent())
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Error creating token"})
+		c.Error(err)
 		return
 	}
-	sameSite := http.SameSiteNoneMode // Важно установить SameSite=None с Secure=true
-
-	//c.SetCookie("token", token, 3600*72, "/", "", true, true)
-	//c.SetSameSite(sameSite)
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:     "token",
-		Value:    token,
-		Expires:  time.Now().Add(time.Hour * 24),
-		Path:     "/",
-		Domain:   "",
-		SameSite: sameSite,
-		Secure:   true,
-		HttpOnly: true,
-	})
-
-	c.IndentedJSON(http.StatusOK, gin.H{"id": id})
-	log.Infof("Success login: %v", id)
+
+	result, err := h.setAuthCookies(c, currSession, refreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, result)
+	logger.FromContext(c.Request.Context()).WithField("personId", id).Info("logged in")
+}
+
+// bearerToken returns the token of the Authorization header, or the token
+// cookie when the header is absent.
+func bearerToken(c *gin.Context) (string, error) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return "", http.ErrNoCookie
+		}
+		return strings.TrimSpace(token), nil
+	}
+	return c.Cookie("token")
 }
 
 func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, err := c.Cookie("token")
-
+		tokenString, err := bearerToken(c)
 		if err != nil {
-			c.AbortWithStatus(http.StatusUnauthorized)
+			c.Error(fmt.Errorf("%w: %s", db.ErrUnauthorized, err.Error()))
+			c.Abort()
 			return
 		}
-
-		token, err := parseToken(tokenString)
-		if err != nil || !token.Valid {
-			c.AbortWithStatus(http.StatusUnauthorized)
+		if err = h.authenticate(c, tokenString); err != nil {
+			c.Error(err)
+			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			c.AbortWithStatus(http.StatusUnauthorized)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware authenticates the caller of a route open to
+// anonymous callers. A request without a token goes on as anonymous, one
+// with a token is checked as by AuthMiddleware, so a caller who logged in
+// keeps their role and scope and a bad token is still refused.
+func (h *Handler) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if _, err := c.Cookie("token"); err != nil {
+				c.Next()
+				return
+			}
+		}
+		tokenString, err := bearerToken(c)
+		if err != nil {
+			c.Error(fmt.Errorf("%w: %s", db.ErrUnauthorized, err.Error()))
+			c.Abort()
+			return
+		}
+		if err = h.authenticate(c, tokenString); err != nil {
+			c.Error(err)
+			c.Abort()
 			return
 		}
-
-		c.Set("userId", claims["id"])
 
 		c.Next()
 	}
 }
+
+// authenticate checks the access token and its session and sets userId,
+// sessionId and role of the caller.
+func (h *Handler) authenticate(c *gin.Context, tokenString string) error {
+	claims, err := h.tokens.Parse(tokenString)
+	if err != nil {
+		return fmt.Errorf("%w: %s", db.ErrUnauthorized, err.Error())
+	}
+
+	// Отозванная сессия не принимается даже с живым токеном
+	if err = h.service.CheckSession(c.Request.Context(), claims.SessionID()); err != nil {
+		if errors.Is(err, db.ErrNotExist) {
+			err = fmt.Errorf("%w: %s", db.ErrUnauthorized, err.Error())
+		}
+		return err
+	}
+
+	c.Set("userId", claims.ID)
+	c.Set("sessionId", claims.SessionID())
+	c.Set("role", claims.Role)
+	return nil
+}
+
+// RequireRole lets through only people whose token carries one of the roles.
+// A change of role revokes the sessions of the person, so the role of a
+// token that passed AuthMiddleware is current. It must run after
+// AuthMiddleware.
+func (h *Handler) RequireRole(roles ...people.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := people.Role(c.GetString("role"))
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.Error(fmt.Errorf("%w: role %q", db.ErrForbidden, role))
+		c.Abort()
+	}
+}
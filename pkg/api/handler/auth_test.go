@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		cookie string
+		want   string
+		ok     bool
+	}{
+		{name: "header", header: "Bearer header-token", want: "header-token", ok: true},
+		{name: "scheme in lower case", header: "bearer header-token", want: "header-token", ok: true},
+		{name: "cookie", cookie: "cookie-token", want: "cookie-token", ok: true},
+		{name: "header before cookie", header: "Bearer header-token", cookie: "cookie-token", want: "header-token", ok: true},
+		{name: "another scheme", header: "Basic dXNlcjpwYXNz", cookie: "cookie-token"},
+		{name: "empty bearer", header: "Bearer  "},
+		{name: "nothing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				c.Request.AddCookie(&http.Cookie{Name: "token", Value: tt.cookie})
+			}
+
+			got, err := bearerToken(c)
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/task"
+	"effectiveMobile/pkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	log "github.com/sirupsen/logrus"
+)
+
+// problemTypePrefix Тип проблемы - URN, по коду его можно найти в документации
+const problemTypePrefix = "urn:effective-mobile:problem:"
+
+// Problem is an RFC 7807 error response.
+// @swagger:model
+type Problem struct {
+	Type     string       `json:"type" example:"urn:effective-mobile:problem:not_found"`
+	Title    string       `json:"title" example:"Resource not found"`
+	Status   int          `json:"status" example:"404"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance" example:"/people/task/42"`
+	Code     string       `json:"code" example:"not_found"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError tells which field of the request failed validation and why.
+// @swagger:model
+type FieldError struct {
+	Field   string `json:"field" example:"passportNumber"`
+	Message string `json:"message" example:"is required"`
+}
+
+// Error is what a client learns about a failed request: a stable code for
+// programs, the HTTP status and a message safe to show. The wrapped error
+// is only logged.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Detail  string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Message, e.Err.Error())
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var errInternal = Error{Status: http.StatusInternalServerError, Code: "internal", Message: "Internal server error"}
+
+// errorMappings Одна и та же ошибка везде дает один и тот же ответ
+var errorMappings = []struct {
+	target error
+	Error
+}{
+	{db.ErrUnauthorized, Error{Status: http.StatusUnauthorized, Code: "unauthorized", Message: "Authorization required"}},
+	{db.ErrSessionRevoked, Error{Status: http.StatusUnauthorized, Code: "session_revoked", Message: "Session is revoked"}},
+	{db.ErrSessionExpired, Error{Status: http.StatusUnauthorized, Code: "session_expired", Message: "Session is expired"}},
+	{db.ErrTokenReuse, Error{Status: http.StatusUnauthorized, Code: "token_reuse", Message: "Refresh token reuse detected, please log in again"}},
+	{db.ErrForbidden, Error{Status: http.StatusForbidden, Code: "forbidden", Message: "Access denied"}},
+	{db.ErrParamNotFound, Error{Status: http.StatusBadRequest, Code: "invalid_param", Message: "Invalid or missing id"}},
+	{db.ErrValidate, Error{Status: http.StatusBadRequest, Code: "validation_failed", Message: "Validation failed"}},
+	{db.ErrPassportSerie, Error{Status: http.StatusBadRequest, Code: "invalid_passport_serie", Message: "Passport serie is not valid"}},
+	{db.ErrPassportNumber, Error{Status: http.StatusBadRequest, Code: "invalid_passport_number", Message: "Passport number is not valid"}},
+	{db.ErrTimeInvalidFormat, Error{Status: http.StatusBadRequest, Code: "invalid_time_format", Message: "Invalid time format", Detail: "Expected a UTC time like 2006-01-02T15:04:05Z"}},
+	{db.ErrNotExist, Error{Status: http.StatusNotFound, Code: "not_found", Message: "Resource not found"}},
+	{db.ErrUpdateFailed, Error{Status: http.StatusNotFound, Code: "not_found", Message: "Resource not found"}},
+	{db.ErrDeleteFailed, Error{Status: http.StatusNotFound, Code: "not_found", Message: "Resource not found"}},
+	{db.ErrDuplicate, Error{Status: http.StatusConflict, Code: "duplicate", Message: "Resource already exists"}},
+	{db.ErrTimerRunning, Error{Status: http.StatusConflict, Code: "timer_running", Message: "Another task is running", Detail: "Pause or finish it, or start with onRunning=pause or onRunning=finish"}},
+	{db.ErrTimeOverlap, Error{Status: http.StatusConflict, Code: "time_overlap", Message: "Time overlaps another task of the person"}},
+	{db.ErrTaskState, Error{Status: http.StatusConflict, Code: "invalid_task_state", Message: "Task state does not allow this action"}},
+}
+
+// detailer is an error whose Detail is safe to show to the client, such as
+// a refused status change of a task.
+type detailer interface {
+	Detail() string
+}
+
+// toError translates err into the error the client sees. Unknown errors
+// become a generic internal error so their text never leaves the server.
+func toError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.target) {
+			result := mapping.Error
+			result.Fields = fieldErrors(err)
+			result.Err = err
+			var d detailer
+			if errors.As(err, &d) {
+				result.Detail = d.Detail()
+			}
+			return &result
+		}
+	}
+	result := errInternal
+	result.Err = err
+	return &result
+}
+
+// badRequest reports a request body or query that could not be bound.
+func badRequest(err error) *Error {
+	result := &Error{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_request",
+		Message: "Invalid request",
+		Fields:  fieldErrors(err),
+		Err:     err,
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case len(result.Fields) > 0:
+		result.Code = "validation_failed"
+		result.Message = "Validation failed"
+	case errors.Is(err, io.EOF):
+		result.Detail = "Request body is empty"
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		result.Detail = "Request body is not valid JSON"
+	case errors.As(err, &typeErr):
+		result.Fields = []FieldError{{Field: typeErr.Field, Message: "must be " + typeErr.Type.String()}}
+	}
+	return result
+}
+
+// fieldErrors lists the failed fields of a validator error or a task field
+// error wrapped in err.
+func fieldErrors(err error) []FieldError {
+	var fieldErr *task.FieldError
+	if errors.As(err, &fieldErr) {
+		return []FieldError{{Field: fieldErr.Field, Message: fieldErr.Message}}
+	}
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	result := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		result = append(result, FieldError{Field: lowerFirst(fe.Field()), Message: fieldMessage(fe)})
+	}
+	return result
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	default:
+		return "is not valid"
+	}
+}
+
+// lowerFirst turns a Go field name into its json name, PassportNumber
+// becomes passportNumber. Fields named through RegisterTagNameFunc stay as is.
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// ErrorMiddleware writes the problem+json response of a request whose
+// handler recorded an error with c.Error, so handlers never build error
+// bodies themselves.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		apiErr := toError(c.Errors.Last().Err)
+
+		entry := logger.FromContext(c.Request.Context()).WithFields(log.Fields{
+			"method": c.Request.Method,
+			"path":   c.FullPath(),
+			"status": apiErr.Status,
+			"code":   apiErr.Code,
+		})
+		if apiErr.Status >= http.StatusInternalServerError {
+			entry.Error(apiErr.Error())
+		} else {
+			entry.Warn(apiErr.Error())
+		}
+
+		if c.Writer.Written() {
+			return
+		}
+		c.Header("Content-Type", "application/problem+json")
+		c.JSON(apiErr.Status, Problem{
+			Type:     problemTypePrefix + apiErr.Code,
+			Title:    apiErr.Message,
+			Status:   apiErr.Status,
+			Detail:   apiErr.Detail,
+			Instance: c.Request.URL.Path,
+			Code:     apiErr.Code,
+			Errors:   apiErr.Fields,
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/task"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serve answers a request with err through ErrorMiddleware and returns the
+// status and the problem the client gets.
+func serve(t *testing.T, err error) (int, Problem) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ErrorMiddleware())
+	engine.GET("/test", func(c *gin.Context) {
+		c.Error(err)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	return w.Code, problem
+}
+
+// TestErrorMappings wraps every mapped sentinel as a repository or the
+// service would. Wrapped or not, it gives the same response everywhere.
+func TestErrorMappings(t *testing.T) {
+	for _, mapping := range errorMappings {
+		t.Run(mapping.Code+"/"+mapping.target.Error(), func(t *testing.T) {
+			for _, err := range []error{mapping.target, fmt.Errorf("get task 7: %w", mapping.target)} {
+				status, problem := serve(t, err)
+				if status != mapping.Status || problem.Status != mapping.Status || problem.Code != mapping.Code {
+					t.Errorf("%v: got %d %s, want %d %s", err, status, problem.Code, mapping.Status, mapping.Code)
+				}
+				if problem.Type != problemTypePrefix+mapping.Code || problem.Title != mapping.Message || problem.Instance != "/test" {
+					t.Errorf("%v: got %+v", err, problem)
+				}
+			}
+		})
+	}
+}
+
+// TestErrorMiddlewareInternal checks that the text of an unknown error
+// stays in the log.
+func TestErrorMiddlewareInternal(t *testing.T) {
+	status, problem := serve(t, fmt.Errorf("scan people: %w", errors.New(`pq: relation "people" does not exist`)))
+	if status != http.StatusInternalServerError || problem.Code != "internal" {
+		t.Errorf("got %d %s, want 500 internal", status, problem.Code)
+	}
+	body, _ := json.Marshal(problem)
+	if strings.Contains(string(body), "people") {
+		t.Errorf("problem leaks the error: %s", body)
+	}
+}
+
+// TestErrorMiddlewareOwnError lets a handler answer with its own code for
+// a mapped sentinel, as Login does for an unknown person.
+func TestErrorMiddlewareOwnError(t *testing.T) {
+	status, problem := serve(t, &Error{Status: http.StatusUnauthorized, Code: "invalid_credentials", Message: "Invalid passport number or password", Err: db.ErrNotExist})
+	if status != http.StatusUnauthorized || problem.Code != "invalid_credentials" {
+		t.Errorf("got %d %s, want 401 invalid_credentials", status, problem.Code)
+	}
+}
+
+func TestErrorMiddlewareTransition(t *testing.T) {
+	err := fmt.Errorf("%w: %w", db.ErrTaskState, &task.TransitionError{TaskID: 7, From: task.StatusFinished, To: task.StatusRunning})
+
+	status, problem := serve(t, err)
+	if status != http.StatusConflict || problem.Code != "invalid_task_state" {
+		t.Errorf("got %d %s, want 409 invalid_task_state", status, problem.Code)
+	}
+	if problem.Detail != "task 7 is finished and can not change anymore" {
+		t.Errorf("detail = %q", problem.Detail)
+	}
+}
@@ -1,15 +1,46 @@
 package handler
 
 import (
+	"effectiveMobile/pkg/config"
 	services "effectiveMobile/pkg/service/interface"
+	"effectiveMobile/pkg/token"
+	"net/http"
+	"strings"
 )
 
 type Handler struct {
 	service services.ServiceUseCase
+	tokens  *token.Manager
+	cookie  cookieConfig
 }
 
-func NewHandler(service services.ServiceUseCase) *Handler {
+type cookieConfig struct {
+	secure   bool
+	sameSite http.SameSite
+	domain   string
+}
+
+func NewHandler(cfg config.Config, service services.ServiceUseCase, tokens *token.Manager) *Handler {
 	return &Handler{
 		service: service,
+		tokens:  tokens,
+		cookie: cookieConfig{
+			secure:   cfg.CookieSecure,
+			sameSite: parseSameSite(cfg.CookieSameSite),
+			domain:   cfg.CookieDomain,
+		},
+	}
+}
+
+func parseSameSite(s string) http.SameSite {
+	switch strings.ToLower(s) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteDefaultMode
 	}
 }
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/logger"
+	"effectiveMobile/pkg/version"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Probe answers the liveness, readiness and version checks of the
+// orchestrator.
+type Probe struct {
+	db       *sql.DB
+	migrator *db.Migrator
+}
+
+func NewProbe(bd *sql.DB, migrator *db.Migrator) *Probe {
+	return &Probe{
+		db:       bd,
+		migrator: migrator,
+	}
+}
+
+// @Summary Liveness probe
+// @Description Reports that the process is up, without touching the database
+// @Tags Health
+// @Produce  json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (p *Probe) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Readiness probe
+// @Description Reports whether the database answers and every migration is applied
+// @Tags Health
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /readyz [get]
+func (p *Probe) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	checks := gin.H{"database": "ok", "migrations": "ok"}
+	status := http.StatusOK
+	if err := p.db.PingContext(ctx); err != nil {
+		logger.FromContext(c.Request.Context()).WithError(err).Warn("readiness: database is unavailable")
+		checks["database"] = "unavailable"
+		checks["migrations"] = "unknown"
+		status = http.StatusServiceUnavailable
+	} else if err := p.migrator.Check(ctx); err != nil {
+		logger.FromContext(c.Request.Context()).WithError(err).Warn("readiness: migrations are pending")
+		checks["migrations"] = "pending"
+		status = http.StatusServiceUnavailable
+	}
+
+	result := "ready"
+	if status != http.StatusOK {
+		result = "unavailable"
+	}
+	c.JSON(status, gin.H{"status": result, "checks": checks})
+}
+
+// @Summary Build information
+// @Description Git commit and build time of the binary, the schema version it ships with and the version applied to the database
+// @Tags Health
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Router /version [get]
+func (p *Probe) Version(c *gin.Context) {
+	result := gin.H{
+		"commit":        version.Commit,
+		"buildTime":     version.BuildTime,
+		"schemaVersion": p.migrator.Latest(),
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	// Версия схемы в базе необязательна, /version отвечает и без базы
+	if applied, err := p.migrator.Version(ctx); err == nil {
+		result["appliedSchemaVersion"] = applied
+	} else {
+		logger.FromContext(c.Request.Context()).WithError(err).Warn("version: schema version is unavailable")
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/logger"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevel is the level of the running logger.
+// @swagger:model
+type LogLevel struct {
+	Level string `json:"level" binding:"required" example:"debug"`
+}
+
+// @Summary Get the log level
+// @Description Level of the running logger
+// @Tags Admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} LogLevel
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Router /log-level [get]
+func (h *Handler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, LogLevel{Level: logger.Level()})
+}
+
+// @Summary Change the log level
+// @Description Changes the level of the running logger without a restart: panic, fatal, error, warn, info, debug or trace
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param level body LogLevel true "New level"
+// @Success 200 {object} LogLevel
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Router /log-level [put]
+func (h *Handler) PutLogLevel(c *gin.Context) {
+	var request LogLevel
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(badRequest(err))
+		return
+	}
+
+	previous := logger.Level()
+	if err := logger.SetLevel(request.Level); err != nil {
+		c.Error(fmt.Errorf("%w: %s", db.ErrValidate, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, LogLevel{Level: logger.Level()})
+	logger.FromContext(c.Request.Context()).WithField("previous", previous).Warnf("log level changed to %s", logger.Level())
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"effectiveMobile/pkg/db"
 	"effectiveMobile/pkg/domain/people"
+	"effectiveMobile/pkg/logger"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,181 +11,239 @@ import (
 )
 
 // @Summary Get info about a person
-// @Description Get info about a person by passport series and number
+// @Description Get info about a person by passport series and number. Employees find only themselves, managers themselves and their reports, admins anyone
 // @Tags People
 // @Produce  json
 // @Param passportSerie query string true "Passport Series"
 // @Param passportNumber query string true "Passport Number"
 // @Success 200 {object} people.Request
-// @Failure 400 gin.H map[string]string
-// @Failure 404 gin.H map[string]string
-// @Failure 500 gin.H map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /info [get]
 func (h *Handler) InfoPeople(c *gin.Context) {
 	passportSerie := c.Query("passportSerie")
 	passportNumber := c.Query("passportNumber")
-	result, err := h.service.InfoPeople(c.Request.Context(), passportSerie, passportNumber)
+	// userId пуст, если маршрут открыт для анонимов
+	result, err := h.service.InfoPeople(c.Request.Context(), c.GetString("userId"), passportSerie, passportNumber)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrValidate.Error():
-			c.JSON(400, gin.H{"message": "params error validate serie or number"})
-			log.Error(err.Error())
-			break
-		case db.ErrPassportSerie.Error():
-			c.JSON(400, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-			break
-		case db.ErrPassportNumber.Error():
-			c.JSON(400, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-			break
-		case db.ErrNotExist.Error():
-			c.JSON(404, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-			break
-		default:
-			c.JSON(500, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-		}
+		c.Error(err)
 		return
 	}
 
 	c.JSON(200, result)
-	log.Info("Success InfoPeople %v", result)
+	logger.FromContext(c.Request.Context()).WithField("personId", result.ID).Debug("people info")
 	return
 }
 
 // @Summary Get list of people
-// @Description Get list of people with optional filters and pagination
+// @Description Get list of people with optional filters and pagination. Employees see themselves, managers themselves and their reports, admins everyone. Passport numbers are masked for non-admins. If ANONYMOUS_ROUTES opens the route, callers without a token see everyone without their tasks
 // @Tags People
 // @Produce  json
 // @Param filter query people.Filter false "Filter parameters"
 // @Param pagination query people.Pagination false "Pagination parameters"
 // @Success 200 {array} people.People
-// @Failure 400 gin.H map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /people [get]
 func (h *Handler) GetPeople(c *gin.Context) {
 	var filter people.Filter
-	if err := c.BindQuery(&filter); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
-		log.Error(err.Error())
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.Error(badRequest(err))
 		return
 	}
 
 	var pagination people.Pagination
-	if err := c.BindQuery(&pagination); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error bind Get pagination": err.Error()})
-		log.Error(err.Error())
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.Error(badRequest(err))
 		return
 	}
 
-	peoples, err := h.service.GetPeople(c.Request.Context(), &filter, &pagination)
+	// userId пуст, если маршрут открыт для анонимов
+	peoples, err := h.service.GetPeople(c.Request.Context(), c.GetString("userId"), &filter, &pagination)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
-		log.Error(err.Error())
+		c.Error(err)
 		return
 	}
 	c.JSON(200, peoples)
-	log.Info("Success GetPeople %v", peoples)
+	logger.FromContext(c.Request.Context()).WithField("count", len(peoples)).Debug("people listed")
 	return
 }
 
 // @Summary Update a person
-// @Description Update person information
+// @Description Update the caller's information, or any person's information for an admin
 // @Tags People
 // @Accept  json
 // @Produce  json
+// @Param personId path string false "Person ID, admin only"
 // @Param people body people.Info true "Update person info"
 // @Success 200 {object} people.Info
-// @Failure 400 gin.H map[string]string
-// @Failure 401 gin.H map[string]string
-// @Failure 409 gin.H map[string]string
-// @Failure 500 gin.H map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /people [put]
+// @Router /people/{personId} [put]
 func (h *Handler) PutPeople(c *gin.Context) {
 	userId, exists := c.Get("userId")
 	if !exists {
-		c.JSON(401, gin.H{"error": "Authorization required"})
-		log.Errorf("Auth error: Authorization required")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
 	id, ok := userId.(string)
 	if !ok {
-		c.JSON(401, gin.H{"error": "Invalid user ID"})
-		log.Errorf("Invalid user ID")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
 
+	personId := c.Param("personId")
+	if personId == "" {
+		personId = id
+	}
+
 	var updatePeople people.Info
-	if err := c.BindJSON(&updatePeople); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if err := c.ShouldBindJSON(&updatePeople); err != nil {
+		c.Error(badRequest(err))
 		return
 	}
 
-	result, err := h.service.PutPeople(c.Request.Context(), id, updatePeople)
+	result, err := h.service.PutPeople(c.Request.Context(), id, personId, updatePeople)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrParamNotFound.Error():
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Account ID is required"})
-			log.Error(err.Error())
-		case db.ErrDuplicate.Error():
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-			log.Error(err.Error())
-		case db.ErrUpdateFailed.Error():
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update people"})
-			log.Error(err.Error())
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-		}
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
-	log.Info("Success PutPeople %v", result)
+	logger.FromContext(c.Request.Context()).WithField("personId", result.ID).Info("person updated")
 	return
 }
 
 // @Summary Delete a person
-// @Description Delete person information
+// @Description Delete the caller, or any person for an admin
 // @Tags People
-// @Success 200 gin.H map[string]string
-// @Failure 401 gin.H map[string]string
-// @Failure 403 gin.H map[string]string
-// @Failure 500 gin.H map[string]string
+// @Param personId path string false "Person ID, admin only"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /people [delete]
+// @Router /people/{personId} [delete]
 func (h *Handler) DeletePeople(c *gin.Context) {
 	userId, exists := c.Get("userId")
 	if !exists {
-		c.JSON(401, gin.H{"error": "Authorization required"})
-		log.Errorf("Auth error: Authorization required")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
 	id, ok := userId.(string)
 	if !ok {
-		c.JSON(401, gin.H{"error": "Invalid user ID"})
-		log.Errorf("Invalid user ID")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
 
-	err := h.service.DeletePeople(c.Request.Context(), id)
+	personId := c.Param("personId")
+	if personId == "" {
+		personId = id
+	}
+
+	err := h.service.DeletePeople(c.Request.Context(), id, personId)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrParamNotFound.Error():
-			c.JSON(400, gin.H{"error": "problems with param"})
-			log.Error(err.Error())
-			break
-		case db.ErrDeleteFailed.Error():
-			c.JSON(403, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-			break
-		default:
-			c.JSON(500, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-		}
-		return
-	}
-	c.JSON(200, gin.H{"id": id})
-	log.Printf("Success DeletePeople %v", id)
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"id": personId})
+	logger.FromContext(c.Request.Context()).WithField("personId", personId).Info("person deleted")
 	return
 }
+
+// @Summary Change the role of a person
+// @Description Set the role and the manager of a person, admin only. A new role revokes the sessions of the person, who logs in again to get it. The manager may not report to the person, directly or through other managers
+// @Tags People
+// @Accept  json
+// @Produce  json
+// @Param personId path string true "Person ID"
+// @Param access body people.Access true "Role and manager"
+// @Success 200 {object} people.Access
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/{personId}/role [put]
+func (h *Handler) PutAccess(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	var access people.Access
+	if err := c.ShouldBindJSON(&access); err != nil {
+		c.Error(badRequest(err))
+		return
+	}
+
+	personId := c.Param("personId")
+	result, err := h.service.PutAccess(c.Request.Context(), id, personId, access)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"personId": personId, "role": result.Role}).Info("role changed")
+}
+
+// @Summary Change the timer policy of a person
+// @Description Allow a person to run a single task timer at a time or several, admin only
+// @Tags People
+// @Accept  json
+// @Produce  json
+// @Param personId path string true "Person ID"
+// @Param policy body people.TimerPolicy true "Timer policy"
+// @Success 200 {object} people.TimerPolicy
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/{personId}/timer [put]
+func (h *Handler) PutTimerPolicy(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	var policy people.TimerPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.Error(badRequest(err))
+		return
+	}
+
+	personId := c.Param("personId")
+	result, err := h.service.PutTimerPolicy(c.Request.Context(), id, personId, policy)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"personId": personId, "singleTimer": *result.SingleTimer}).Info("timer policy changed")
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/session"
+	"effectiveMobile/pkg/logger"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshRequest carries the refresh token for clients without cookies.
+// @swagger:model
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access and refresh token. Reusing a refresh token revokes every session of its family
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param refresh body RefreshRequest false "Refresh token, if it is not sent as a cookie"
+// @Param includeToken query bool false "Return the access and refresh tokens in the body"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	refreshToken, err := c.Cookie("refresh_token")
+	if err != nil {
+		var req RefreshRequest
+		if err := c.ShouldBindJSON(&req); err == nil {
+			refreshToken = req.RefreshToken
+		}
+	}
+
+	currSession, newRefreshToken, err := h.service.RefreshSession(c.Request.Context(), refreshToken, c.Request.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrTokenReuse):
+			h.clearAuthCookies(c)
+		case errors.Is(err, db.ErrParamNotFound), errors.Is(err, db.ErrNotExist):
+			// Неизвестный refresh токен не должен выглядеть как 400 или 404
+			err = &Error{Status: http.StatusUnauthorized, Code: "invalid_refresh_token", Message: "Invalid refresh token", Err: err}
+		}
+		c.Error(err)
+		return
+	}
+
+	result, err := h.setAuthCookies(c, currSession, newRefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+	logger.FromContext(c.Request.Context()).WithField("personId", currSession.PersonID).Debug("session refreshed")
+}
+
+// @Summary Logout
+// @Description Revoke the current session
+// @Tags User
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /logout [post]
+func (h *Handler) Logout(c *gin.Context) {
+	sessionId := c.GetString("sessionId")
+	if sessionId == "" {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	err := h.service.Logout(c.Request.Context(), sessionId)
+	if err != nil && !errors.Is(err, db.ErrNotExist) {
+		c.Error(err)
+		return
+	}
+
+	h.clearAuthCookies(c)
+	c.JSON(http.StatusOK, gin.H{"id": sessionId})
+	logger.FromContext(c.Request.Context()).WithField("sessionId", sessionId).Info("logged out")
+}
+
+// @Summary Get active sessions
+// @Description Get the active sessions of the current person
+// @Tags User
+// @Produce  json
+// @Success 200 {object} object{data=[]session.Session}
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /sessions [get]
+func (h *Handler) GetSessions(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	result, err := h.service.GetSessions(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithField("count", len(result)).Debug("sessions listed")
+}
+
+// @Summary Revoke a session
+// @Description Revoke one of the sessions of the current person
+// @Tags User
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /sessions/{sessionId} [delete]
+func (h *Handler) DeleteSession(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	sessionId := c.Param("sessionId")
+	err := h.service.RevokeSession(c.Request.Context(), id, sessionId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": sessionId})
+	logger.FromContext(c.Request.Context()).WithField("sessionId", sessionId).Info("session revoked")
+}
+
+// setAuthCookies issues an access token of the session and sets it together
+// with the refresh token. It returns the response body, which carries the
+// tokens too when the client asks for them with includeToken=true.
+func (h *Handler) setAuthCookies(c *gin.Context, currSession *session.Session, refreshToken string) (gin.H, error) {
+	token, expiresAt, err := h.tokens.Create(strconv.FormatInt(currSession.PersonID, 10), currSession.ID, string(currSession.Role))
+	if err != nil {
+		return nil, err
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "token",
+		Value:    token,
+		Expires:  expiresAt,
+		Path:     "/",
+		Domain:   h.cookie.domain,
+		SameSite: h.cookie.sameSite,
+		Secure:   h.cookie.secure,
+		HttpOnly: true,
+	})
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Expires:  currSession.ExpiresAt,
+		Path:     "/refresh",
+		Domain:   h.cookie.domain,
+		SameSite: h.cookie.sameSite,
+		Secure:   h.cookie.secure,
+		HttpOnly: true,
+	})
+
+	result := gin.H{"id": currSession.PersonID}
+	if includeToken, _ := strconv.ParseBool(c.Query("includeToken")); includeToken {
+		result["token"] = token
+		result["tokenType"] = "Bearer"
+		result["expiresAt"] = expiresAt
+		result["refreshToken"] = refreshToken
+		result["refreshExpiresAt"] = currSession.ExpiresAt
+	}
+	return result, nil
+}
+
+func (h *Handler) clearAuthCookies(c *gin.Context) {
+	for name, path := range map[string]string{"token": "/", "refresh_token": "/refresh"} {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			MaxAge:   -1,
+			Path:     path,
+			Domain:   h.cookie.domain,
+			SameSite: h.cookie.sameSite,
+			Secure:   h.cookie.secure,
+			HttpOnly: true,
+		})
+	}
+}
@@ -3,168 +3,429 @@ package handler
 import (
 	"effectiveMobile/pkg/db"
 	"effectiveMobile/pkg/domain/task"
+	"effectiveMobile/pkg/logger"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
 
 // @Summary Start a task for a person
-// @Description Start a task for a person
+// @Description Start a task for a person. If the person may run a single timer, onRunning tells what happens to the running task: reject with 409 (default), pause or finish it
 // @Tags Tasks
 // @Accept  json
 // @Produce  json
 // @Param task body task.Task true "Task info"
-// @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param onRunning query string false "What to do with the running task" Enums(reject, pause, finish)
+// @Success 201 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /people/task/start [post]
 func (h *Handler) StartTask(c *gin.Context) {
 	userId, exists := c.Get("userId")
 	if !exists {
-		c.JSON(401, gin.H{"error": "Authorization required"})
-		log.Errorf("Auth error: Authorization required")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
 	id, ok := userId.(string)
 	if !ok {
-		c.JSON(401, gin.H{"error": "Invalid user ID"})
-		log.Errorf("Invalid user ID")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
 
 	var currTask task.Task
-	if err := c.BindJSON(&currTask); err != nil {
-		c.JSON(400, gin.H{"error bind Task people": err.Error()})
-		log.Error("error bind Task people %v", err.Error())
+	if err := c.ShouldBindJSON(&currTask); err != nil {
+		c.Error(badRequest(err))
 		return
 	}
 
-	result, err := h.service.TaskStart(c.Request.Context(), id, currTask)
+	onRunning := task.OnRunning(c.Query("onRunning"))
+	result, err := h.service.TaskStart(c.Request.Context(), id, currTask, onRunning)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrDuplicate.Error():
-			c.JSON(409, "Email already exist")
-			log.Error("Register email failed %v", err.Error())
-			break
-		default:
-			c.JSON(500, gin.H{"error Task people": err.Error()})
-			log.Error("error service Task people %v", err.Error())
-		}
+		c.Error(err)
 		return
 	}
 
 	c.JSON(201, gin.H{"data": result})
-	log.Info("Success registration: %v", result)
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("task started")
 }
 
 // @Summary Finish a task for a person
-// @Description Finish a task for a person
+// @Description Finish a running or paused task, its total time is the sum of the intervals it ran. A finished, cancelled or planned task can not be finished
 // @Tags Tasks
 // @Produce  json
 // @Param taskId path string true "Task ID"
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /people/task/finish/{taskId} [post]
 func (h *Handler) FinishTask(c *gin.Context) {
 	userId, exists := c.Get("userId")
 	if !exists {
-		c.JSON(401, gin.H{"error": "Authorization required"})
-		log.Errorf("Auth error: Authorization required")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
-	_, ok := userId.(string)
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	taskId := c.Param("taskId")
+	result, err := h.service.TaskFinish(c.Request.Context(), id, taskId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("task finished")
+}
+
+// @Summary Plan a task for a person
+// @Description Create a planned task without starting its timer
+// @Tags Tasks
+// @Accept  json
+// @Produce  json
+// @Param task body task.Task true "Task info"
+// @Success 201 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/task/plan [post]
+func (h *Handler) PlanTask(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	var currTask task.Task
+	if err := c.ShouldBindJSON(&currTask); err != nil {
+		c.Error(badRequest(err))
+		return
+	}
+
+	result, err := h.service.TaskPlan(c.Request.Context(), id, currTask)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(201, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("task planned")
+}
+
+// @Summary Record a manual time entry
+// @Description Record work done without a timer as a finished task with the given start and end time. The end must be after the start and not in the future. If the person may run a single timer, the entry must not overlap other tasks of the person. The total time is computed by the server
+// @Tags Tasks
+// @Accept  json
+// @Produce  json
+// @Param entry body task.Entry true "Time entry"
+// @Success 201 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/task [post]
+func (h *Handler) CreateTask(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	var entry task.Entry
+	if err := c.ShouldBindJSON(&entry); err != nil {
+		c.Error(badRequest(err))
+		return
+	}
+
+	result, err := h.service.TaskRecord(c.Request.Context(), id, entry)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(201, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("time entry recorded")
+}
+
+// @Summary Correct a task
+// @Description Change the name, description or times of a task. The start time moves the start of the first interval, the end time, allowed on finished and cancelled tasks only, moves the end of the last one. The total time is recomputed by the server
+// @Tags Tasks
+// @Accept  json
+// @Produce  json
+// @Param taskId path string true "Task ID"
+// @Param patch body task.Patch true "Fields to change"
+// @Success 200 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/task/{taskId} [patch]
+func (h *Handler) PatchTask(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
 	if !ok {
-		c.JSON(401, gin.H{"error": "Invalid user ID"})
-		log.Errorf("Invalid user ID")
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	var patch task.Patch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.Error(badRequest(err))
 		return
 	}
 
 	taskId := c.Param("taskId")
-	result, err := h.service.TaskFinish(c.Request.Context(), taskId)
+	result, err := h.service.TaskPatch(c.Request.Context(), id, taskId, patch)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrParamNotFound.Error():
-			c.JSON(403, gin.H{"error": "Task not found"})
-			log.Error("Register task failed %v", err.Error())
-			return
-		case db.ErrUpdateFailed.Error():
-			c.JSON(400, gin.H{"error": "Update failed"})
-			log.Error(err.Error())
-			return
-		default:
-			c.JSON(500, gin.H{"error": "Internal server error on FinishTask"})
-			log.Error(err.Error())
-			return
-		}
+		c.Error(err)
+		return
 	}
 	c.JSON(200, gin.H{"data": result})
-	log.Info("Success finish task: %v", result)
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("task corrected")
 }
 
-// @Summary Get tasks for a person
-// @Description Get tasks for a person within a time range
+// @Summary Start a planned task
+// @Description Start the timer of a planned task. If the person may run a single timer, onRunning tells what happens to the running task: reject with 409 (default), pause or finish it
 // @Tags Tasks
 // @Produce  json
+// @Param taskId path string true "Task ID"
+// @Param onRunning query string false "What to do with the running task" Enums(reject, pause, finish)
+// @Success 200 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/task/{taskId}/start [post]
+func (h *Handler) StartPlannedTask(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	taskId := c.Param("taskId")
+	onRunning := task.OnRunning(c.Query("onRunning"))
+	result, err := h.service.TaskRun(c.Request.Context(), id, taskId, onRunning)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("planned task started")
+}
+
+// @Summary Cancel a task
+// @Description Close a planned, running or paused task that will not be done, the time already worked stays in its total time
+// @Tags Tasks
+// @Produce  json
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/task/{taskId}/cancel [post]
+func (h *Handler) CancelTask(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	taskId := c.Param("taskId")
+	result, err := h.service.TaskCancel(c.Request.Context(), id, taskId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("task cancelled")
+}
+
+// @Summary Pause a task
+// @Description Stop the timer of a running task, the pause does not count in its total time. Any other status is a conflict
+// @Tags Tasks
+// @Produce  json
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/task/{taskId}/pause [post]
+func (h *Handler) PauseTask(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	taskId := c.Param("taskId")
+	result, err := h.service.TaskPause(c.Request.Context(), id, taskId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("task paused")
+}
+
+// @Summary Resume a task
+// @Description Start the timer of a paused task again. A planned task is started. If the person may run a single timer, onRunning tells what happens to the running task: reject with 409 (default), pause or finish it
+// @Tags Tasks
+// @Produce  json
+// @Param taskId path string true "Task ID"
+// @Param onRunning query string false "What to do with the running task" Enums(reject, pause, finish)
+// @Success 200 {object} object{data=task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/task/{taskId}/resume [post]
+func (h *Handler) ResumeTask(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	taskId := c.Param("taskId")
+	onRunning := task.OnRunning(c.Query("onRunning"))
+	result, err := h.service.TaskRun(c.Request.Context(), id, taskId, onRunning)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"taskId": result.ID, "personId": result.PersonID}).Info("task resumed")
+}
+
+// @Summary Get labor cost of a person
+// @Description Get the tasks a person worked on within a time range, from the most time spent to the least, with hours and minutes and the total. Without personId the report is for the caller, managers and admins may ask for anyone. Time spent counts only the work inside the range, a running task up to now
+// @Tags Tasks
+// @Produce  json
+// @Param personId query string false "Person ID, the caller if empty"
 // @Param startTime query string true "Start Time"
 // @Param endTime query string true "End Time"
-// @Success 200 {array} task.Task
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param status query string false "Comma separated statuses to keep: planned, running, paused, finished, cancelled"
+// @Success 200 {object} object{data=task.Report}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /people/task/ [get]
 func (h *Handler) GetTask(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	personId := c.Query("personId")
 	startTime := c.Query("startTime")
 	endTime := c.Query("endTime")
+	status := c.Query("status")
 
-	result, err := h.service.GetTask(c.Request.Context(), startTime, endTime)
+	result, err := h.service.GetTask(c.Request.Context(), id, personId, startTime, endTime, status)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrParamNotFound.Error():
-			c.JSON(403, gin.H{"error": "Task not found"})
-			log.Error("Register task failed %v", err.Error())
-			return
-		case db.ErrNotExist.Error():
-			c.JSON(404, gin.H{"error": "Task not found"})
-			log.Error("Get task %v", err.Error())
-			return
-		default:
-			c.JSON(500, gin.H{"error Task people": err.Error()})
-			log.Error("error service Task people %v", err.Error())
-			return
-		}
+		c.Error(err)
+		return
 	}
 	c.JSON(200, gin.H{"data": result})
-	log.Info("Success get task: %v", result)
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"personId": result.PersonID, "count": len(result.Tasks)}).Debug("labor cost reported")
 	return
 }
 
 // @Summary Get list of tasks
-// @Description Get list of all tasks
+// @Description Get list of the caller's tasks, or of all tasks for an admin
 // @Tags Tasks
 // @Produce  json
-// @Success 200 {array} task.Task
-// @Failure 500 {object} map[string]string
+// @Param status query string false "Comma separated statuses to keep: planned, running, paused, finished, cancelled"
+// @Success 200 {object} object{data=[]task.Task}
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /tasks [get]
 func (h *Handler) GetAllTask(c *gin.Context) {
-	result, err := h.service.GetAllTask(c.Request.Context())
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	status := c.Query("status")
+	result, err := h.service.GetAllTask(c.Request.Context(), id, status)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrParamNotFound.Error():
-			c.JSON(403, gin.H{"error": "Tasks not found"})
-			log.Error("Register task failed %v", err.Error())
-			return
-		case db.ErrNotExist.Error():
-			c.JSON(404, gin.H{"error": "Tasks not found"})
-			log.Error("Get task %v", err.Error())
-			return
-		default:
-			c.JSON(500, gin.H{"error Tasks people": err.Error()})
-			log.Error("error service Tasks people %v", err.Error())
-			return
-		}
+		c.Error(err)
+		return
 	}
 	c.JSON(200, gin.H{"data": result})
-	log.Info("Success get tasks: %v", result)
+	logger.FromContext(c.Request.Context()).WithField("count", len(result)).Debug("tasks listed")
 	return
 }
 
@@ -173,42 +434,67 @@ func (h *Handler) GetAllTask(c *gin.Context) {
 // @Tags Tasks
 // @Param taskId path string true "Task ID"
 // @Success 200 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /people/task/{taskId} [delete]
 func (h *Handler) DeleteTask(c *gin.Context) {
 	userId, exists := c.Get("userId")
 	if !exists {
-		c.JSON(401, gin.H{"error": "Authorization required"})
-		log.Errorf("Auth error: Authorization required")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
 	id, ok := userId.(string)
 	if !ok {
-		c.JSON(401, gin.H{"error": "Invalid user ID"})
-		log.Errorf("Invalid user ID")
+		c.Error(db.ErrUnauthorized)
 		return
 	}
 
-	err := h.service.DeleteTask(c.Request.Context(), id)
+	taskId := c.Param("taskId")
+	err := h.service.DeleteTask(c.Request.Context(), id, taskId)
 	if err != nil {
-		switch err.Error() {
-		case db.ErrParamNotFound.Error():
-			c.JSON(400, gin.H{"error": "problems with param"})
-			log.Error(err.Error())
-			break
-		case db.ErrDeleteFailed.Error():
-			c.JSON(403, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-			break
-		default:
-			c.JSON(500, gin.H{"error": err.Error()})
-			log.Error(err.Error())
-		}
-		return
-	}
-	c.JSON(200, gin.H{"id": id})
-	log.Printf("Success DeletePeople %v", id)
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"id": taskId})
+	logger.FromContext(c.Request.Context()).WithField("taskId", taskId).Info("task deleted")
 	return
 }
+
+// @Summary Get tasks of a person
+// @Description Get every task of a person, available to their manager and to admins
+// @Tags Tasks
+// @Produce  json
+// @Param personId path string true "Person ID"
+// @Param status query string false "Comma separated statuses to keep: planned, running, paused, finished, cancelled"
+// @Success 200 {object} object{data=[]task.Task}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /people/{personId}/tasks [get]
+func (h *Handler) GetPersonTasks(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+	id, ok := userId.(string)
+	if !ok {
+		c.Error(db.ErrUnauthorized)
+		return
+	}
+
+	personId := c.Param("personId")
+	status := c.Query("status")
+	result, err := h.service.GetPersonTasks(c.Request.Context(), id, personId, status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"data": result})
+	logger.FromContext(c.Request.Context()).WithFields(log.Fields{"personId": personId, "count": len(result)}).Debug("person tasks listed")
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestRequireRole calls a route of the managers group and one of the
+// admins group with the token of every role.
+func TestRequireRole(t *testing.T) {
+	routes := []struct {
+		name    string
+		target  string
+		allowed map[string]bool
+		expect  func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "managers",
+			target:  "/people/1/tasks",
+			allowed: map[string]bool{"admin": true, "manager": true},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM task").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "startTime", "endTime", "totalTime", "personId", "status"}))
+			},
+		},
+		{
+			name:    "admins",
+			target:  "/log-level",
+			allowed: map[string]bool{"admin": true},
+			expect:  func(sqlmock.Sqlmock) {},
+		},
+	}
+	for _, route := range routes {
+		for _, role := range []string{"employee", "manager", "admin"} {
+			t.Run(route.name+"/"+role, func(t *testing.T) {
+				server, mock, tokens := newTestServerWith(t, testConfig())
+				expectSession(mock)
+				want := http.StatusForbidden
+				if route.allowed[role] {
+					route.expect(mock)
+					want = http.StatusOK
+				}
+				accessToken, _, err := tokens.Create("1", "session", role)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				request := httptest.NewRequest(http.MethodGet, route.target, nil)
+				request.Header.Set("Authorization", "Bearer "+accessToken)
+				recorder := httptest.NewRecorder()
+				server.engine.ServeHTTP(recorder, request)
+
+				if recorder.Code != want {
+					t.Errorf("GET %s as %s = %d, want %d: %s", route.target, role, recorder.Code, want, recorder.Body.String())
+				}
+				if err = mock.ExpectationsWereMet(); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
@@ -1,48 +1,170 @@
 package api
 
 import (
+	"context"
 	"effectiveMobile/pkg/api/handler"
+	"effectiveMobile/pkg/config"
+	"effectiveMobile/pkg/domain/people"
+	"effectiveMobile/pkg/logger"
+	"effectiveMobile/pkg/metrics"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+	"time"
 
 	_ "effectiveMobile/docs"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type ServerHTTP struct {
-	engine *gin.Engine
+	engine          *gin.Engine
+	server          *http.Server
+	shutdownTimeout time.Duration
+	closers         []io.Closer
 }
 
-func NewServerHTTP(userHandler *handler.Handler) *ServerHTTP {
+func NewServerHTTP(cfg config.Config, userHandler *handler.Handler, probe *handler.Probe) *ServerHTTP {
 	engine := gin.New()
 
-	// Use logger from Gin
-	engine.Use(gin.Logger())
+	// Span of every request, continuing the trace of an inbound traceparent
+	engine.Use(otelgin.Middleware(cfg.TracingServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		switch r.URL.Path {
+		case "/healthz", "/readyz", "/metrics":
+			return false
+		}
+		return true
+	})))
 
-	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	// Request id and access log, after otelgin so that lines carry the trace id
+	engine.Use(logger.GinMiddleware())
+	engine.Use(metrics.GinMiddleware())
+	engine.Use(handler.ErrorMiddleware())
+
+	// Probes of the orchestrator
+	engine.GET("/healthz", probe.Healthz)
+	engine.GET("/readyz", probe.Readyz)
+	engine.GET("/version", probe.Version)
+	engine.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	engine.POST("/registration", userHandler.Registration)
 	engine.POST("/login", userHandler.Login)
-	engine.GET("/info", userHandler.InfoPeople)
-	engine.GET("/people", userHandler.GetPeople)
-	engine.GET("/tasks", userHandler.GetAllTask)
+	engine.POST("/refresh", userHandler.Refresh)
 
 	// Use middleware from Gin
-	engine.Use(userHandler.AuthMiddleware())
+	authorized := engine.Group("/", userHandler.AuthMiddleware())
+
+	//Sessions
+	authorized.POST("/logout", userHandler.Logout)
+	authorized.GET("/sessions", userHandler.GetSessions)
+	authorized.DELETE("/sessions/:sessionId", userHandler.DeleteSession)
 
 	//Peoples
-	engine.PUT("/people", userHandler.PutPeople)
-	engine.DELETE("/people", userHandler.DeletePeople)
+	authorized.PUT("/people", userHandler.PutPeople)
+	authorized.DELETE("/people", userHandler.DeletePeople)
 
 	//Task
-	engine.GET("/people/task/", userHandler.GetTask)
-	engine.POST("/people/task/start", userHandler.StartTask)
-	engine.POST("/people/task/finish/:taskId", userHandler.FinishTask)
-	engine.DELETE("/people/task/:taskId", userHandler.DeleteTask)
+	authorized.GET("/tasks", userHandler.GetAllTask)
+	authorized.GET("/people/task/", userHandler.GetTask)
+	authorized.POST("/people/task", userHandler.CreateTask)
+	authorized.POST("/people/task/start", userHandler.StartTask)
+	authorized.POST("/people/task/plan", userHandler.PlanTask)
+	authorized.POST("/people/task/finish/:taskId", userHandler.FinishTask)
+	authorized.POST("/people/task/:taskId/pause", userHandler.PauseTask)
+	authorized.POST("/people/task/:taskId/resume", userHandler.ResumeTask)
+	authorized.POST("/people/task/:taskId/start", userHandler.StartPlannedTask)
+	authorized.POST("/people/task/:taskId/cancel", userHandler.CancelTask)
+	authorized.PATCH("/people/task/:taskId", userHandler.PatchTask)
+	authorized.DELETE("/people/task/:taskId", userHandler.DeleteTask)
+
+	//Managers
+	managers := authorized.Group("/", userHandler.RequireRole(people.RoleAdmin, people.RoleManager))
+	managers.GET("/people/:personId/tasks", userHandler.GetPersonTasks)
+
+	//Admins
+	admins := authorized.Group("/", userHandler.RequireRole(people.RoleAdmin))
+	admins.PUT("/people/:personId", userHandler.PutPeople)
+	admins.DELETE("/people/:personId", userHandler.DeletePeople)
+	admins.PUT("/people/:personId/role", userHandler.PutAccess)
+	admins.PUT("/people/:personId/timer", userHandler.PutTimerPolicy)
+	admins.GET("/log-level", userHandler.GetLogLevel)
+	admins.PUT("/log-level", userHandler.PutLogLevel)
+
+	// Routes that the config can open to anonymous callers
+	anonymous := make(map[string]bool)
+	for _, route := range strings.Split(cfg.AnonymousRoutes, ",") {
+		anonymous[strings.TrimSpace(route)] = true
+	}
+	optional := engine.Group("/", userHandler.OptionalAuthMiddleware())
+	guarded := func(route string, group gin.IRoutes) gin.IRoutes {
+		if anonymous[route] {
+			return optional
+		}
+		return group
+	}
+	guarded("/swagger", authorized).GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	guarded("/info", authorized).GET("/info", userHandler.InfoPeople)
+	guarded("/people", authorized).GET("/people", userHandler.GetPeople)
+
+	return &ServerHTTP{
+		engine: engine,
+		server: &http.Server{
+			Addr:              cfg.HTTPAddr,
+			Handler:           engine,
+			ReadTimeout:       cfg.HTTPReadTimeout,
+			ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+			WriteTimeout:      cfg.HTTPWriteTimeout,
+			IdleTimeout:       cfg.HTTPIdleTimeout,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// AddCloser registers a resource, such as the database pool, to close once
+// the server has stopped serving requests.
+func (sh *ServerHTTP) AddCloser(closer io.Closer) {
+	sh.closers = append(sh.closers, closer)
+}
+
+// Start serves requests until ctx is cancelled, then stops accepting new
+// connections, waits up to the shutdown timeout for in-flight requests and
+// closes the registered resources.
+func (sh *ServerHTTP) Start(ctx context.Context) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.FromContext(ctx).WithField("addr", sh.server.Addr).Info("listening")
+		serveErr <- sh.server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		// Сервер не смог даже начать работу, например адрес занят
+		return errors.Join(err, sh.close())
+	case <-ctx.Done():
+	}
+
+	logger.FromContext(ctx).WithField("timeout", sh.shutdownTimeout.String()).Info("shutting down, waiting for requests to finish")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), sh.shutdownTimeout)
+	defer cancel()
 
-	return &ServerHTTP{engine: engine}
+	err := sh.server.Shutdown(shutdownCtx)
+	if err != nil {
+		// Не уложились в срок, обрываем оставшиеся соединения
+		err = errors.Join(err, sh.server.Close())
+	}
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	return errors.Join(err, sh.close())
 }
 
-func (sh *ServerHTTP) Start() {
-	sh.engine.Run("0.0.0.0:8001")
+func (sh *ServerHTTP) close() error {
+	var err error
+	for i := len(sh.closers) - 1; i >= 0; i-- {
+		err = errors.Join(err, sh.closers[i].Close())
+	}
+	return err
 }
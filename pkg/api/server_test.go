@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"effectiveMobile/pkg/api/handler"
+	"effectiveMobile/pkg/config"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/repo"
+	"effectiveMobile/pkg/repo/people"
+	"effectiveMobile/pkg/repo/session"
+	"effectiveMobile/pkg/repo/task"
+	"effectiveMobile/pkg/service"
+	"effectiveMobile/pkg/token"
+	"effectiveMobile/pkg/tracing"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestTracePropagation sends GET /people with a traceparent and checks
+// the span tree: the server span continues the inbound trace, service spans
+// are its children and every query is a child of the service span that
+// ran it.
+func TestTracePropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider("test", exporter)
+	defer provider.Close()
+
+	server, mock, accessToken := newTestServer(t)
+
+	now := time.Now().UTC()
+	expectSession(mock)
+	mock.ExpectQuery("SELECT id, role, managerId FROM people").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "managerId"}).AddRow(int64(1), "employee", nil))
+	mock.ExpectQuery("SELECT id, name, surname, patronymic, address, passportNumber FROM people").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "address", "passportNumber"}).
+			AddRow(int64(1), "Ivan", "Ivanov", "Ivanovich", "Moscow", "1234 567890"))
+	mock.ExpectQuery("FROM task WHERE personId = ANY").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "startTime", "endTime", "totalTime", "personId", "status"}).
+			AddRow(int64(1), "task", "", now, nil, nil, int64(1), "running"))
+
+	const traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentId = "00f067aa0ba902b7"
+	request := httptest.NewRequest(http.MethodGet, "/people", nil)
+	request.Header.Set("traceparent", "00-"+traceId+"-"+parentId+"-01")
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	recorder := httptest.NewRecorder()
+	server.engine.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /people = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub)
+	var queries []tracetest.SpanStub
+	for _, span := range spans {
+		if span.SpanContext.TraceID().String() != traceId {
+			t.Errorf("span %q is in trace %s, want the inbound %s", span.Name, span.SpanContext.TraceID(), traceId)
+		}
+		if strings.HasPrefix(span.Name, "SELECT ") {
+			queries = append(queries, span)
+			continue
+		}
+		byName[span.Name] = span
+	}
+
+	var serverSpan tracetest.SpanStub
+	for _, span := range spans {
+		if span.SpanKind == trace.SpanKindServer {
+			serverSpan = span
+		}
+	}
+	if serverSpan.Name == "" {
+		t.Fatalf("no server span among %d spans", len(spans))
+	}
+	if got := serverSpan.Parent.SpanID().String(); got != parentId {
+		t.Errorf("server span parent = %s, want the inbound span %s", got, parentId)
+	}
+
+	checkSession, ok := byName["service.CheckSession"]
+	if !ok {
+		t.Fatal("no service.CheckSession span")
+	}
+	getPeople, ok := byName["service.GetPeople"]
+	if !ok {
+		t.Fatal("no service.GetPeople span")
+	}
+	for _, span := range []tracetest.SpanStub{checkSession, getPeople} {
+		if span.Parent.SpanID() != serverSpan.SpanContext.SpanID() {
+			t.Errorf("%s is not a child of the server span", span.Name)
+		}
+	}
+
+	if len(queries) != 4 {
+		t.Fatalf("got %d query spans, want 4", len(queries))
+	}
+	for _, query := range queries {
+		want := getPeople
+		if query.Name == "SELECT session" {
+			want = checkSession
+		}
+		if query.Parent.SpanID() != want.SpanContext.SpanID() {
+			t.Errorf("query span %q is not a child of %s", query.Name, want.Name)
+		}
+		if query.SpanKind != trace.SpanKindClient {
+			t.Errorf("query span %q kind = %v, want client", query.Name, query.SpanKind)
+		}
+	}
+}
+
+// newTestServer builds the server over sqlmock and returns it with an
+// access token of person 1, an employee.
+func newTestServer(t *testing.T) (*ServerHTTP, sqlmock.Sqlmock, string) {
+	t.Helper()
+	server, mock, tokens := newTestServerWith(t, testConfig())
+	accessToken, _, err := tokens.Create("1", "session", "employee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, mock, accessToken
+}
+
+func testConfig() config.Config {
+	return config.Config{
+		JWTAlgorithm:       "HS256",
+		JWTKeys:            "test:secret",
+		JWTTTL:             time.Minute,
+		TracingServiceName: "test",
+	}
+}
+
+// newTestServerWith builds the server of cfg over sqlmock and returns it
+// with its token manager.
+func newTestServerWith(t *testing.T, cfg config.Config) (*ServerHTTP, sqlmock.Sqlmock, *token.Manager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := token.NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userService := service.NewService(cfg, repo.NewUnitOfWork(conn), people.NewPeopleDataBase(conn), task.NewTaskDataBase(conn), session.NewSessionDataBase(conn))
+	server := NewServerHTTP(cfg, handler.NewHandler(cfg, userService, tokens), handler.NewProbe(conn, migrator))
+	return server, mock, tokens
+}
+
+// expectSession answers the session lookup of the access token.
+func expectSession(mock sqlmock.Sqlmock) {
+	now := time.Now().UTC()
+	mock.ExpectQuery("FROM session WHERE id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "personId", "familyId", "userAgent", "createdAt", "expiresAt", "rotatedAt", "revokedAt"}).
+			AddRow("session", int64(1), "family", "test", now, now.Add(time.Hour), nil, nil))
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// startSlow starts the server on a free port with a route that answers
+// once release is closed. It returns the address, a channel closed when the
+// request is in flight and the result of Start.
+func startSlow(t *testing.T, ctx context.Context, server *ServerHTTP, release <-chan struct{}, done *atomic.Bool) (string, <-chan struct{}, <-chan error) {
+	t.Helper()
+	started := make(chan struct{})
+	server.engine.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		done.Store(true)
+		c.String(http.StatusOK, "done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	server.server.Addr = addr
+
+	result := make(chan error, 1)
+	go func() { result <- server.Start(ctx) }()
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if i == 100 {
+			t.Fatalf("server does not listen: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return addr, started, result
+}
+
+// TestStartDrains cancels the context while a request is in flight. The
+// request is answered before Start returns, and the closers run after it
+// in the reverse order of registration.
+func TestStartDrains(t *testing.T) {
+	cfg := testConfig()
+	cfg.ShutdownTimeout = 5 * time.Second
+	server, _, _ := newTestServerWith(t, cfg)
+
+	var mu sync.Mutex
+	var closed []string
+	var done atomic.Bool
+	for _, name := range []string{"db", "tracer"} {
+		server.AddCloser(closerFunc(func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !done.Load() {
+				t.Errorf("%s closed before the request finished", name)
+			}
+			closed = append(closed, name)
+			return nil
+		}))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	release := make(chan struct{})
+	addr, started, result := startSlow(t, ctx, server, release, &done)
+
+	type response struct {
+		body string
+		err  error
+	}
+	answered := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			answered <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		answered <- response{string(body), err}
+	}()
+
+	<-started
+	cancel()
+	// Shutdown ждёт запрос, Start не должен вернуться раньше него
+	select {
+	case err := <-result:
+		t.Fatalf("Start returned %v with a request in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	if r := <-answered; r.err != nil || r.body != "done" {
+		t.Errorf("got %q, %v, want done", r.body, r.err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Start: %v", err)
+	}
+	if want := []string{"tracer", "db"}; !slices.Equal(closed, want) {
+		t.Errorf("closed %v, want %v", closed, want)
+	}
+}
+
+// TestStartTimeout gives up on a request that outlives the shutdown
+// timeout. Start reports it and still closes the resources.
+func TestStartTimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+	server, _, _ := newTestServerWith(t, cfg)
+
+	var closed atomic.Bool
+	server.AddCloser(closerFunc(func() error {
+		closed.Store(true)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	release := make(chan struct{})
+	defer close(release)
+	var done atomic.Bool
+	addr, started, result := startSlow(t, ctx, server, release, &done)
+
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	if err := <-result; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if !closed.Load() {
+		t.Error("resources are not closed")
+	}
+}
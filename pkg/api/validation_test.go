@@ -0,0 +1,108 @@
+package api
+
+import (
+	"effectiveMobile/pkg/api/handler"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestFieldErrors checks that a wrong field of a request comes back with
+// the field it is about, whether the service refuses it as invalid or as
+// overlapping other work of the person.
+func TestFieldErrors(t *testing.T) {
+	now := time.Now().UTC()
+	entry := func(start, end time.Time) string {
+		body, _ := json.Marshal(map[string]interface{}{"name": "task", "startTime": start, "endTime": end})
+		return string(body)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		expect func(mock sqlmock.Sqlmock)
+		status int
+		code   string
+		field  handler.FieldError
+	}{
+		{
+			name:   "start after end",
+			method: http.MethodGet,
+			target: "/people/task/?startTime=2024-07-02T00:00:00Z&endTime=2024-07-01T00:00:00Z",
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			field:  handler.FieldError{Field: "startTime", Message: "must be before endTime"},
+		},
+		{
+			name:   "future end",
+			method: http.MethodPost,
+			target: "/people/task",
+			body:   entry(now.Add(-time.Hour), now.Add(time.Hour)),
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			field:  handler.FieldError{Field: "endTime", Message: "is in the future"},
+		},
+		{
+			name:   "empty name",
+			method: http.MethodPatch,
+			target: "/people/task/9",
+			body:   `{"name": ""}`,
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			field:  handler.FieldError{Field: "name", Message: "must not be empty"},
+		},
+		{
+			name:   "overlap with single timer",
+			method: http.MethodPost,
+			target: "/people/task",
+			body:   entry(now.Add(-2*time.Hour), now.Add(-time.Hour)),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT singleTimer FROM people").
+					WillReturnRows(sqlmock.NewRows([]string{"singleTimer"}).AddRow(true))
+				mock.ExpectQuery("SELECT task.id FROM task").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(5)))
+				mock.ExpectRollback()
+			},
+			status: http.StatusConflict,
+			code:   "time_overlap",
+			field:  handler.FieldError{Field: "startTime", Message: "overlaps task 5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mock, accessToken := newTestServer(t)
+			expectSession(mock)
+			if tt.expect != nil {
+				tt.expect(mock)
+			}
+
+			request := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			request.Header.Set("Authorization", "Bearer "+accessToken)
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			server.engine.ServeHTTP(recorder, request)
+
+			var problem handler.Problem
+			if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v: %s", err, recorder.Body.String())
+			}
+			if recorder.Code != tt.status || problem.Code != tt.code {
+				t.Errorf("got %d %s, want %d %s", recorder.Code, problem.Code, tt.status, tt.code)
+			}
+			if len(problem.Errors) != 1 || problem.Errors[0] != tt.field {
+				t.Errorf("errors = %v, want [%v]", problem.Errors, tt.field)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -1,32 +1,241 @@
 package config
 
 import (
-	"github.com/joho/godotenv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 )
 
+// DefaultFile Файл настроек, который читается, если он есть
+const DefaultFile = "./app.env"
+
 type Config struct {
 	PsqlUser   string
 	PsqlPass   string
 	PsqlHost   string
 	PsqlPort   string
 	PsqlDBName string
+	// PsqlSSLMode как sslmode в libpq: disable, require, verify-ca, verify-full...
+	PsqlSSLMode     string
+	PsqlSSLRootCert string
+	// PsqlApplicationName Имя в pg_stat_activity
+	PsqlApplicationName string
+	PsqlSearchPath      string
+	// PsqlStatementTimeout Предел на один запрос, 0 - без предела
+	PsqlStatementTimeout time.Duration
+	// PsqlConnectTimeout Сколько ждать базу при старте
+	PsqlConnectTimeout  time.Duration
+	PsqlMaxOpenConns    int
+	PsqlMaxIdleConns    int
+	PsqlConnMaxLifetime time.Duration
+	PsqlConnMaxIdleTime time.Duration
+	BcryptCost          int
+
+	// JWTKeys Ключи подписи в формате kid:value через запятую.
+	// Для HS256 value - секрет, для RS256/EdDSA - путь к PEM файлу
+	JWTKeys       string
+	JWTSigningKid string
+	JWTAlgorithm  string
+	JWTIssuer     string
+	JWTAudience   string
+	JWTTTL        time.Duration
+	RefreshTTL    time.Duration
+
+	// CookieSameSite один из none, lax, strict
+	CookieSecure   bool
+	CookieSameSite string
+	CookieDomain   string
+
+	// AnonymousRoutes Маршруты без авторизации через запятую: /swagger, /info, /people
+	AnonymousRoutes string
+
+	// HTTPAddr Адрес, на котором слушает сервер
+	HTTPAddr              string
+	HTTPReadTimeout       time.Duration
+	HTTPReadHeaderTimeout time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	// ShutdownTimeout Сколько ждать завершения запросов при остановке
+	ShutdownTimeout time.Duration
+
+	// LogFormat json или text, LogLevel меняется на лету через /log-level
+	LogFormat string
+	LogLevel  string
+
+	// TracingExporter один из none, stdout, otlp
+	TracingExporter    string
+	TracingServiceName string
+
+	// values и sources Итоговые значения настроек и откуда они взяты, для config print
+	values  map[string]string
+	sources map[string]string
+}
+
+// LoadConfig builds the config from, in increasing precedence, the defaults,
+// an optional env or YAML file, the environment and the command line flags.
+// The file is the one of --config or CONFIG_FILE, else app.env if it exists.
+// It returns the arguments left after the flags, such as a subcommand, and
+// reports every invalid setting at once.
+func LoadConfig(args []string) (Config, []string, error) {
+	config := Config{
+		values:  make(map[string]string, len(settings)),
+		sources: make(map[string]string, len(settings)),
+	}
+	for _, s := range settings {
+		config.values[s.key] = s.def
+		config.sources[s.key] = "default"
+	}
+
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	file := flags.String("config", os.Getenv("CONFIG_FILE"), "env or YAML file with settings, app.env if it exists")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.key] = flags.String(s.flagName(), "", fmt.Sprintf("%s (%s)", s.usage, s.key))
+	}
+	if err := flags.Parse(args); err != nil {
+		return config, nil, err
+	}
+
+	path, required := *file, true
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	fileValues, err := readFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && !required:
+		// Без файла настройки берутся из окружения, как в Docker
+	case err != nil:
+		return config, nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	for key, value := range fileValues {
+		// Чужие ключи, например OTEL_EXPORTER_OTLP_ENDPOINT, читают библиотеки
+		// из окружения, как раньше после godotenv.Load
+		if _, ok := os.LookupEnv(key); lookup(key) == nil && !ok {
+			if err := os.Setenv(key, value); err != nil {
+				return config, nil, err
+			}
+		}
+	}
+	for _, s := range settings {
+		if value, ok := fileValues[s.key]; ok {
+			config.values[s.key] = value
+			config.sources[s.key] = path
+		}
+		if value, ok := os.LookupEnv(s.key); ok {
+			config.values[s.key] = value
+			config.sources[s.key] = "env"
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if f.Name == s.flagName() {
+				config.values[s.key] = *flagValues[s.key]
+				config.sources[s.key] = "flag"
+			}
+		}
+	})
+
+	var errs []error
+	failed := make(map[string]bool)
+	for _, s := range settings {
+		if err := s.set(&config, strings.TrimSpace(config.values[s.key])); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.key, err))
+			failed[s.key] = true
+		}
+	}
+	errs = append(errs, config.validate(failed)...)
+	return config, flags.Args(), errors.Join(errs...)
 }
 
-func LoadConfig() (Config, error) {
-	var config Config
+// Print writes the settings in the env file format with their sources.
+// Secrets are redacted.
+func (c Config) Print(w io.Writer) error {
+	for _, s := range settings {
+		value := c.values[s.key]
+		if s.secret && value != "" {
+			value = "[REDACTED]"
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s # %s\n", s.key, value, c.sources[s.key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	//Загрузка переменных окружения (если используете godotenv)
-	err := godotenv.Load("./app.env") // Раскомментируйте эту строку, если используете godotenv
-	if err != nil {
-		return config, err
+// validate checks the settings that depend on each other. A check is
+// skipped when one of its settings failed to parse, its error is reported
+// already.
+func (c Config) validate(failed map[string]bool) []error {
+	parsed := func(keys ...string) bool {
+		for _, key := range keys {
+			if failed[key] {
+				return false
+			}
+		}
+		return true
 	}
-	// Получение значений переменных окружения
-	config.PsqlUser = os.Getenv("POSTGRES_USER")
-	config.PsqlPass = os.Getenv("POSTGRES_PASSWORD")
-	config.PsqlHost = os.Getenv("POSTGRES_HOST")
-	config.PsqlDBName = os.Getenv("POSTGRES_DB")
-	config.PsqlPort = os.Getenv("POSTGRES_PORT")
 
-	return config, err
+	var errs []error
+	if parsed("POSTGRES_MAX_OPEN_CONNS", "POSTGRES_MAX_IDLE_CONNS") && c.PsqlMaxOpenConns > 0 && c.PsqlMaxIdleConns > c.PsqlMaxOpenConns {
+		errs = append(errs, fmt.Errorf("POSTGRES_MAX_IDLE_CONNS: must not exceed POSTGRES_MAX_OPEN_CONNS (%d)", c.PsqlMaxOpenConns))
+	}
+	if c.PsqlSSLRootCert != "" {
+		if _, err := os.Stat(c.PsqlSSLRootCert); err != nil {
+			errs = append(errs, fmt.Errorf("POSTGRES_SSLROOTCERT: %w", err))
+		}
+		if parsed("POSTGRES_SSLMODE") && c.PsqlSSLMode != "verify-ca" && c.PsqlSSLMode != "verify-full" {
+			errs = append(errs, errors.New("POSTGRES_SSLROOTCERT: is only used with POSTGRES_SSLMODE verify-ca or verify-full"))
+		}
+	}
+	if parsed("JWT_KEYS") && c.JWTSigningKid != "" && !hasKid(c.JWTKeys, c.JWTSigningKid) {
+		errs = append(errs, fmt.Errorf("JWT_SIGNING_KID: key %q is not in JWT_KEYS", c.JWTSigningKid))
+	}
+	if parsed("JWT_KEYS", "JWT_ALGORITHM") {
+		errs = append(errs, checkSecrets(c.JWTAlgorithm, c.JWTKeys)...)
+	}
+	if parsed("JWT_TTL", "JWT_REFRESH_TTL") && c.RefreshTTL <= c.JWTTTL {
+		errs = append(errs, errors.New("JWT_REFRESH_TTL: must be longer than JWT_TTL"))
+	}
+	if parsed("COOKIE_SAMESITE", "COOKIE_SECURE") && c.CookieSameSite == "none" && !c.CookieSecure {
+		errs = append(errs, errors.New("COOKIE_SAMESITE: none requires COOKIE_SECURE=true"))
+	}
+	return errs
+}
+
+// checkSecrets makes sure every HMAC secret is at least as long as the hash
+// of the algorithm, 32 bytes for HS256, as RFC 7518 requires.
+func checkSecrets(algorithm string, keys string) []error {
+	var size int
+	switch algorithm {
+	case "HS256":
+		size = 32
+	case "HS384":
+		size = 48
+	case "HS512":
+		size = 64
+	default:
+		return nil
+	}
+	var errs []error
+	for _, pair := range strings.Split(keys, ",") {
+		kid, secret, _ := strings.Cut(strings.TrimSpace(pair), ":")
+		if len(secret) < size {
+			errs = append(errs, fmt.Errorf("JWT_KEYS: secret of key %q must be at least %d bytes for %s", kid, size, algorithm))
+		}
+	}
+	return errs
+}
+
+func hasKid(keys string, kid string) bool {
+	for _, pair := range strings.Split(keys, ",") {
+		if k, _, ok := strings.Cut(strings.TrimSpace(pair), ":"); ok && k == kid {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSecret = "test:0123456789abcdef0123456789abcdef"
+
+// clearEnv unsets every setting for the test, so that the environment of
+// the machine does not leak into the layers under test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range append([]string{"CONFIG_FILE"}, keys()...) {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func keys() []string {
+	result := make([]string, 0, len(settings))
+	for _, s := range settings {
+		result = append(result, s.key)
+	}
+	return result
+}
+
+func writeFile(t *testing.T, name string, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadConfigPrecedence sets settings in several layers: a flag beats
+// the environment, which beats the file, which beats the default.
+func TestLoadConfigPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "app.env", strings.Join([]string{
+		"POSTGRES_USER=user",
+		"POSTGRES_HOST=file-host",
+		"POSTGRES_DB=file-db",
+		"POSTGRES_PORT=5433",
+		"JWT_KEYS=" + testSecret,
+	}, "\n"))
+	t.Setenv("POSTGRES_HOST", "env-host")
+	t.Setenv("POSTGRES_DB", "env-db")
+
+	cfg, args, err := LoadConfig([]string{"--config", path, "--postgres-host", "flag-host", "migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		got    string
+		want   string
+		source string
+	}{
+		{"POSTGRES_APPLICATION_NAME", cfg.PsqlApplicationName, "effectiveMobile", "default"},
+		{"POSTGRES_PORT", cfg.PsqlPort, "5433", path},
+		{"POSTGRES_DB", cfg.PsqlDBName, "env-db", "env"},
+		{"POSTGRES_HOST", cfg.PsqlHost, "flag-host", "flag"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want || cfg.sources[tt.key] != tt.source {
+			t.Errorf("%s = %q from %s, want %q from %s", tt.key, tt.got, cfg.sources[tt.key], tt.want, tt.source)
+		}
+	}
+	if strings.Join(args, " ") != "migrate up" {
+		t.Errorf("args = %v, want [migrate up]", args)
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "app.yaml", `
+postgres:
+  user: user
+  host: db
+  db: people
+  max_idle_conns: 5
+jwt:
+  keys: "`+testSecret+`"
+`)
+	cfg, _, err := LoadConfig([]string{"--config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PsqlHost != "db" || cfg.PsqlMaxIdleConns != 5 {
+		t.Errorf("got host %q and %d idle conns, want db and 5", cfg.PsqlHost, cfg.PsqlMaxIdleConns)
+	}
+}
+
+// TestLoadConfigErrors reports a setting that does not parse together with
+// the cross-field errors of the others. A check that depends on the broken
+// setting is skipped rather than reported on its zero value.
+func TestLoadConfigErrors(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "app.env", strings.Join([]string{
+		"POSTGRES_HOST=db",
+		"POSTGRES_DB=people",
+		"POSTGRES_MAX_OPEN_CONNS=many",
+		"JWT_KEYS=old:short," + testSecret,
+		"JWT_TTL=soon",
+		"COOKIE_SECURE=false",
+		"COOKIE_SAMESITE=none",
+	}, "\n"))
+
+	_, _, err := LoadConfig([]string{"--config", path})
+	if err == nil {
+		t.Fatal("LoadConfig succeeded, want errors")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		"POSTGRES_USER: is required",
+		"POSTGRES_MAX_OPEN_CONNS:",
+		"JWT_TTL:",
+		`JWT_KEYS: secret of key "old" must be at least 32 bytes for HS256`,
+		"COOKIE_SAMESITE: none requires COOKIE_SECURE=true",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("errors do not report %q:\n%s", want, msg)
+		}
+	}
+	for _, unwanted := range []string{
+		`secret of key "test"`,
+		"JWT_REFRESH_TTL",
+		"POSTGRES_MAX_IDLE_CONNS",
+	} {
+		if strings.Contains(msg, unwanted) {
+			t.Errorf("errors report %q:\n%s", unwanted, msg)
+		}
+	}
+}
+
+func TestCheckSecrets(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		keys      string
+		errors    int
+	}{
+		{"HS256", testSecret, 0},
+		{"HS256", "a:", 1},
+		{"HS256", "a:short, b:also short", 2},
+		{"HS512", testSecret, 1},
+		{"RS256", "a:key.pem", 0},
+		{"EdDSA", "a:key.pem", 0},
+	}
+	for _, tt := range tests {
+		if got := checkSecrets(tt.algorithm, tt.keys); len(got) != tt.errors {
+			t.Errorf("checkSecrets(%s, %q) = %v, want %d errors", tt.algorithm, tt.keys, got, tt.errors)
+		}
+	}
+}
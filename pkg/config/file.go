@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads the settings of an env file, or of a YAML file when the
+// name ends in .yaml or .yml. Nested YAML keys are joined with an
+// underscore, so postgres: {host: db} sets POSTGRES_HOST.
+func readFile(path string) (map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var tree map[string]interface{}
+		if err = yaml.Unmarshal(body, &tree); err != nil {
+			return nil, err
+		}
+		values := make(map[string]string)
+		flatten("", tree, values)
+		return values, nil
+	default:
+		// godotenv.Read, в отличие от Load, не трогает окружение
+		return godotenv.Read(path)
+	}
+}
+
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		key = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(key, v, values)
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setting is one configuration value: its environment variable, the flag
+// derived from it, the default and how it lands in Config.
+type setting struct {
+	key    string
+	def    string
+	usage  string
+	secret bool
+	set    func(c *Config, value string) error
+}
+
+// flagName turns POSTGRES_MAX_OPEN_CONNS into postgres-max-open-conns.
+func (s setting) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(s.key, "_", "-"))
+}
+
+var settings = []setting{
+	// PostgreSQL
+	{key: "POSTGRES_USER", usage: "database user", set: required(stringVar(func(c *Config) *string { return &c.PsqlUser }))},
+	{key: "POSTGRES_PASSWORD", usage: "database password", secret: true, set: stringVar(func(c *Config) *string { return &c.PsqlPass })},
+	{key: "POSTGRES_HOST", usage: "database host", set: required(stringVar(func(c *Config) *string { return &c.PsqlHost }))},
+	{key: "POSTGRES_PORT", def: "5432", usage: "database port", set: portVar(func(c *Config) *string { return &c.PsqlPort })},
+	{key: "POSTGRES_DB", usage: "database name", set: required(stringVar(func(c *Config) *string { return &c.PsqlDBName }))},
+	{key: "POSTGRES_SSLMODE", def: "disable", usage: "sslmode of the database connection", set: oneOf(func(c *Config) *string { return &c.PsqlSSLMode }, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")},
+	{key: "POSTGRES_SSLROOTCERT", usage: "CA certificate that verifies the database server", set: stringVar(func(c *Config) *string { return &c.PsqlSSLRootCert })},
+	{key: "POSTGRES_APPLICATION_NAME", def: "effectiveMobile", usage: "application_name of the database connections", set: stringVar(func(c *Config) *string { return &c.PsqlApplicationName })},
+	{key: "POSTGRES_SEARCH_PATH", usage: "search_path of the database connections, the server default if empty", set: stringVar(func(c *Config) *string { return &c.PsqlSearchPath })},
+	{key: "POSTGRES_STATEMENT_TIMEOUT", def: "30s", usage: "statement_timeout of the database connections, 0 is unlimited", set: limitVar(func(c *Config) *time.Duration { return &c.PsqlStatementTimeout })},
+	{key: "POSTGRES_CONNECT_TIMEOUT", def: "1m", usage: "how long to wait for the database on startup", set: durationVar(func(c *Config) *time.Duration { return &c.PsqlConnectTimeout })},
+	{key: "POSTGRES_MAX_OPEN_CONNS", def: "20", usage: "max open database connections, 0 is unlimited", set: intVar(func(c *Config) *int { return &c.PsqlMaxOpenConns }, 0, 10000)},
+	{key: "POSTGRES_MAX_IDLE_CONNS", def: "10", usage: "max idle database connections", set: intVar(func(c *Config) *int { return &c.PsqlMaxIdleConns }, 0, 10000)},
+	{key: "POSTGRES_CONN_MAX_LIFETIME", def: "30m", usage: "how long a database connection is reused, 0 is forever", set: limitVar(func(c *Config) *time.Duration { return &c.PsqlConnMaxLifetime })},
+	{key: "POSTGRES_CONN_MAX_IDLE_TIME", def: "5m", usage: "how long a database connection stays idle, 0 is forever", set: limitVar(func(c *Config) *time.Duration { return &c.PsqlConnMaxIdleTime })},
+
+	// Auth
+	{key: "BCRYPT_COST", def: strconv.Itoa(bcrypt.DefaultCost), usage: "bcrypt cost of password hashes", set: intVar(func(c *Config) *int { return &c.BcryptCost }, bcrypt.MinCost, bcrypt.MaxCost)},
+	{key: "JWT_ALGORITHM", def: "HS256", usage: "signing algorithm of access tokens", set: oneOf(func(c *Config) *string { return &c.JWTAlgorithm }, "HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "EdDSA")},
+	{key: "JWT_KEYS", usage: "signing keys as kid:value, comma separated, HMAC secrets at least as long as the hash", secret: true, set: required(stringVar(func(c *Config) *string { return &c.JWTKeys }))},
+	{key: "JWT_SIGNING_KID", usage: "kid of the key new tokens are signed with, the first key if empty", set: stringVar(func(c *Config) *string { return &c.JWTSigningKid })},
+	{key: "JWT_ISSUER", usage: "iss claim of access tokens", set: stringVar(func(c *Config) *string { return &c.JWTIssuer })},
+	{key: "JWT_AUDIENCE", usage: "aud claim of access tokens", set: stringVar(func(c *Config) *string { return &c.JWTAudience })},
+	{key: "JWT_TTL", def: "15m", usage: "lifetime of access tokens", set: durationVar(func(c *Config) *time.Duration { return &c.JWTTTL })},
+	{key: "JWT_REFRESH_TTL", def: "720h", usage: "lifetime of refresh tokens", set: durationVar(func(c *Config) *time.Duration { return &c.RefreshTTL })},
+
+	// Cookies
+	{key: "COOKIE_SECURE", def: "true", usage: "set the Secure attribute of auth cookies", set: boolVar(func(c *Config) *bool { return &c.CookieSecure })},
+	{key: "COOKIE_SAMESITE", def: "none", usage: "SameSite attribute of auth cookies", set: oneOf(func(c *Config) *string { return &c.CookieSameSite }, "none", "lax", "strict")},
+	{key: "COOKIE_DOMAIN", usage: "Domain attribute of auth cookies", set: stringVar(func(c *Config) *string { return &c.CookieDomain })},
+
+	// Routes
+	{key: "ANONYMOUS_ROUTES", usage: "routes open without authorization: /swagger, /info, /people", set: stringVar(func(c *Config) *string { return &c.AnonymousRoutes })},
+
+	// HTTP server
+	{key: "HTTP_ADDR", def: "0.0.0.0:8001", usage: "address the server listens on", set: required(stringVar(func(c *Config) *string { return &c.HTTPAddr }))},
+	{key: "HTTP_READ_TIMEOUT", def: "15s", usage: "max time to read a request", set: durationVar(func(c *Config) *time.Duration { return &c.HTTPReadTimeout })},
+	{key: "HTTP_READ_HEADER_TIMEOUT", def: "5s", usage: "max time to read request headers", set: durationVar(func(c *Config) *time.Duration { return &c.HTTPReadHeaderTimeout })},
+	{key: "HTTP_WRITE_TIMEOUT", def: "30s", usage: "max time to write a response", set: durationVar(func(c *Config) *time.Duration { return &c.HTTPWriteTimeout })},
+	{key: "HTTP_IDLE_TIMEOUT", def: "2m", usage: "how long keep-alive connections stay idle", set: durationVar(func(c *Config) *time.Duration { return &c.HTTPIdleTimeout })},
+	{key: "SHUTDOWN_TIMEOUT", def: "20s", usage: "how long to wait for requests on shutdown", set: durationVar(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+
+	// Logging
+	{key: "LOG_FORMAT", def: "json", usage: "log format", set: oneOf(func(c *Config) *string { return &c.LogFormat }, "json", "text")},
+	{key: "LOG_LEVEL", def: "info", usage: "log level", set: oneOf(func(c *Config) *string { return &c.LogLevel }, "panic", "fatal", "error", "warn", "warning", "info", "debug", "trace")},
+
+	// Tracing
+	{key: "TRACING_EXPORTER", def: "none", usage: "where spans are exported", set: oneOf(func(c *Config) *string { return &c.TracingExporter }, "none", "stdout", "otlp")},
+	{key: "OTEL_SERVICE_NAME", def: "effectiveMobile", usage: "service name of the spans", set: required(stringVar(func(c *Config) *string { return &c.TracingServiceName }))},
+}
+
+func lookup(key string) *setting {
+	for i := range settings {
+		if settings[i].key == key {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+func stringVar(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func required(set func(c *Config, value string) error) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		if value == "" {
+			return fmt.Errorf("is required")
+		}
+		return set(c, value)
+	}
+}
+
+func oneOf(field func(c *Config) *string, allowed ...string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		for _, a := range allowed {
+			if strings.EqualFold(value, a) {
+				*field(c) = a
+				return nil
+			}
+		}
+		return fmt.Errorf("%q must be one of: %s", value, strings.Join(allowed, ", "))
+	}
+}
+
+func portVar(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%q is not a valid port", value)
+		}
+		*field(c) = value
+		return nil
+	}
+}
+
+func intVar(field func(c *Config) *int, min int, max int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		if n < min || n > max {
+			return fmt.Errorf("%d must be between %d and %d", n, min, max)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolVar(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// durationVar reads a positive duration like 15s or 2m.
+func durationVar(field func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 15s or 2m", value)
+		}
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// limitVar reads a duration like durationVar, where 0 means no limit.
+func limitVar(field func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 15s or 2m", value)
+		}
+		if d < 0 {
+			return fmt.Errorf("%s must not be negative", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
@@ -1,25 +1,90 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"effectiveMobile/pkg/config"
+	"effectiveMobile/pkg/logger"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
+	log "github.com/sirupsen/logrus"
 )
 
-// ConnectToBD Подключение к PostgresSql по app.env
-func ConnectToBD(cfg config.Config) (*sql.DB, error) {
-	// Формирование строки подключения из конфига
-	addr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", cfg.PsqlUser, cfg.PsqlPass, cfg.PsqlHost, cfg.PsqlPort, cfg.PsqlDBName)
-	fmt.Printf("\nConnecting to %s\n", addr)
-	psqlInfo := addr
+// ConnectToBD Подключение к PostgresSql по настройкам POSTGRES_*
+func ConnectToBD(ctx context.Context, cfg config.Config) (*sql.DB, error) {
+	dsn := DSN(cfg)
+	// Пароль в лог не попадает
+	logger.FromContext(ctx).WithField("dsn", dsn.Redacted()).Info("connecting to database")
 
 	// Подключение к БД
-	db, err := sql.Open("pgx", psqlInfo)
+	db, err := sql.Open("pgx", dsn.String())
 	if err != nil {
 		return nil, err
 	}
+	db.SetMaxOpenConns(cfg.PsqlMaxOpenConns)
+	db.SetMaxIdleConns(cfg.PsqlMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.PsqlConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.PsqlConnMaxIdleTime)
 
 	return db, nil
 }
+
+// DSN builds the connection URL of the config. Every part is escaped, so
+// a password or a database name with @, / or ? cannot break it. Parameters
+// pgx does not know, search_path and statement_timeout, are sent to the
+// server as run-time parameters of every connection.
+func DSN(cfg config.Config) *url.URL {
+	query := url.Values{}
+	query.Set("sslmode", cfg.PsqlSSLMode)
+	if cfg.PsqlSSLRootCert != "" {
+		query.Set("sslrootcert", cfg.PsqlSSLRootCert)
+	}
+	if cfg.PsqlApplicationName != "" {
+		query.Set("application_name", cfg.PsqlApplicationName)
+	}
+	if cfg.PsqlSearchPath != "" {
+		query.Set("search_path", cfg.PsqlSearchPath)
+	}
+	if cfg.PsqlStatementTimeout > 0 {
+		query.Set("statement_timeout", strconv.FormatInt(cfg.PsqlStatementTimeout.Milliseconds(), 10))
+	}
+
+	return &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.PsqlUser, cfg.PsqlPass),
+		Host:     net.JoinHostPort(cfg.PsqlHost, cfg.PsqlPort),
+		Path:     "/" + cfg.PsqlDBName,
+		RawQuery: query.Encode(),
+	}
+}
+
+// WaitForDB pings the database until it answers, doubling the pause between
+// attempts, and gives up after timeout. sql.Open never connects by itself,
+// so without this the server would start against a dead database.
+func WaitForDB(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+		err := db.PingContext(pingCtx)
+		pingCancel()
+		if err == nil {
+			return nil
+		}
+		logger.FromContext(ctx).WithError(err).WithFields(log.Fields{"attempt": attempt, "retryIn": backoff.String()}).Warn("database is not ready")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not reachable after %s: %w", timeout, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 10*time.Second)
+	}
+}
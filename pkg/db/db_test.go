@@ -0,0 +1,82 @@
+package db
+
+import (
+	"effectiveMobile/pkg/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// TestDSN builds the URL of a config and reads it back as pgx does, so a
+// part the URL does not escape shows up as a wrong user, password or
+// database rather than as a string that merely looks right.
+func TestDSN(t *testing.T) {
+	base := config.Config{
+		PsqlUser:    "people",
+		PsqlPass:    "secret",
+		PsqlHost:    "db",
+		PsqlPort:    "5432",
+		PsqlDBName:  "people",
+		PsqlSSLMode: "disable",
+	}
+	tests := []struct {
+		name   string
+		change func(c *config.Config)
+		tls    bool
+		params map[string]string
+	}{
+		{name: "plain", change: func(*config.Config) {}},
+		{name: "special characters", change: func(c *config.Config) {
+			c.PsqlUser = "app@people"
+			c.PsqlPass = "p@ss:w/rd?#%&= ü"
+			c.PsqlDBName = "people db"
+		}},
+		{name: "ipv6 host", change: func(c *config.Config) { c.PsqlHost = "::1" }},
+		{name: "sslmode", change: func(c *config.Config) { c.PsqlSSLMode = "require" }, tls: true},
+		{
+			name: "run-time parameters",
+			change: func(c *config.Config) {
+				c.PsqlApplicationName = "effectiveMobile"
+				c.PsqlSearchPath = "tenant,public"
+				c.PsqlStatementTimeout = 1500 * time.Millisecond
+			},
+			params: map[string]string{"application_name": "effectiveMobile", "search_path": "tenant,public", "statement_timeout": "1500"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.change(&cfg)
+
+			parsed, err := pgx.ParseConfig(DSN(cfg).String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.User != cfg.PsqlUser || parsed.Password != cfg.PsqlPass || parsed.Database != cfg.PsqlDBName || parsed.Host != cfg.PsqlHost || parsed.Port != 5432 {
+				t.Errorf("got %s:%s@%s:%d/%s", parsed.User, parsed.Password, parsed.Host, parsed.Port, parsed.Database)
+			}
+			if (parsed.TLSConfig != nil) != tt.tls {
+				t.Errorf("TLS = %v, want %v", parsed.TLSConfig != nil, tt.tls)
+			}
+			for key, want := range tt.params {
+				if got := parsed.RuntimeParams[key]; got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDSNRedacted(t *testing.T) {
+	cfg := config.Config{PsqlUser: "people", PsqlPass: "p@ss:w/rd", PsqlHost: "db", PsqlPort: "5432", PsqlDBName: "people", PsqlSSLMode: "disable"}
+
+	redacted := DSN(cfg).Redacted()
+	if want := "postgres://people:xxxxx@db:5432/people?sslmode=disable"; redacted != want {
+		t.Errorf("got %s, want %s", redacted, want)
+	}
+	if strings.Contains(redacted, "p@ss") || strings.Contains(redacted, "p%40ss") {
+		t.Errorf("%s shows the password", redacted)
+	}
+}
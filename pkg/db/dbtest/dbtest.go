@@ -0,0 +1,94 @@
+// Package dbtest gives integration tests a PostgreSQL schema of their own.
+// The database comes from the POSTGRES_* environment the app reads, tests
+// are skipped when POSTGRES_HOST is not set.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/pkg/config"
+	"effectiveMobile/pkg/db"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var schemas atomic.Int64
+
+// Open connects to a new empty schema, dropped with everything in it when
+// the test ends.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	cfg := envConfig(t)
+
+	admin, err := sql.Open("pgx", db.DSN(cfg).String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	schema := fmt.Sprintf("test_%d_%d_%d", os.Getpid(), time.Now().UnixNano(), schemas.Add(1))
+	if _, err = admin.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	schemaCfg := cfg
+	schemaCfg.PsqlSearchPath = schema
+	conn, err := sql.Open("pgx", db.DSN(schemaCfg).String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		admin, err := sql.Open("pgx", db.DSN(cfg).String())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer admin.Close()
+		if _, err = admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+	return conn
+}
+
+// Migrated is Open with every migration applied.
+func Migrated(t testing.TB) *sql.DB {
+	t.Helper()
+	conn := Open(t)
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return conn
+}
+
+func envConfig(t testing.TB) config.Config {
+	host := os.Getenv("POSTGRES_HOST")
+	if host == "" {
+		t.Skip("POSTGRES_HOST is not set, skipping the database test")
+	}
+	cfg := config.Config{
+		PsqlUser:    os.Getenv("POSTGRES_USER"),
+		PsqlPass:    os.Getenv("POSTGRES_PASSWORD"),
+		PsqlHost:    host,
+		PsqlPort:    os.Getenv("POSTGRES_PORT"),
+		PsqlDBName:  os.Getenv("POSTGRES_DB"),
+		PsqlSSLMode: os.Getenv("POSTGRES_SSLMODE"),
+	}
+	if cfg.PsqlPort == "" {
+		cfg.PsqlPort = "5432"
+	}
+	if cfg.PsqlSSLMode == "" {
+		cfg.PsqlSSLMode = "disable"
+	}
+	return cfg
+}
@@ -15,4 +15,12 @@ var (
 	ErrPassportSerie     = errors.New("passport serie not valid")
 	ErrPassportNumber    = errors.New("passport number not valid")
 	ErrTimeInvalidFormat = errors.New("invalid time format")
+	ErrUnauthorized      = errors.New("authorization required")
+	ErrForbidden         = errors.New("access denied")
+	ErrSessionRevoked    = errors.New("session revoked")
+	ErrSessionExpired    = errors.New("session expired")
+	ErrTokenReuse        = errors.New("refresh token reuse detected")
+	ErrTaskState         = errors.New("task state does not allow the action")
+	ErrTimerRunning      = errors.New("another task of the person is running")
+	ErrTimeOverlap       = errors.New("time overlaps another task of the person")
 )
@@ -0,0 +1,8 @@
+package db
+
+import "database/sql"
+
+// NewMigratorWith lets tests run their own migrations.
+func NewMigratorWith(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/pkg/metrics"
+	"effectiveMobile/pkg/tracing"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type instrumentedDB struct {
+	db         DBTX
+	repository string
+}
+
+// Instrument wraps db so that every query of the repository is measured
+// and recorded as a child span of the span in the context.
+func Instrument(db DBTX, repository string) DBTX {
+	return &instrumentedDB{
+		db:         db,
+		repository: repository,
+	}
+}
+
+func (i *instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span, start := i.start(ctx, query)
+	result, err := i.db.ExecContext(ctx, query, args...)
+	i.end(span, query, start, err)
+	return result, err
+}
+
+func (i *instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span, start := i.start(ctx, query)
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	i.end(span, query, start, err)
+	return rows, err
+}
+
+func (i *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span, start := i.start(ctx, query)
+	row := i.db.QueryRowContext(ctx, query, args...)
+	// sql.ErrNoRows появляется только при Scan и ошибкой запроса не считается
+	i.end(span, query, start, row.Err())
+	return row
+}
+
+func (i *instrumentedDB) start(ctx context.Context, query string) (context.Context, trace.Span, time.Time) {
+	operation := metrics.Operation(query)
+	// Аргументы в span не пишем, в них бывают пароли и паспорта
+	ctx, span := tracing.Tracer().Start(ctx, strings.ToUpper(operation)+" "+i.repository,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+			attribute.String("db.repository", i.repository),
+		),
+	)
+	return ctx, span, time.Now()
+}
+
+func (i *instrumentedDB) end(span trace.Span, query string, start time.Time, err error) {
+	metrics.ObserveQuery(i.repository, query, start, err)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
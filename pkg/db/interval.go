@@ -0,0 +1,91 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Длина месяца и года как в EXTRACT(EPOCH FROM interval)
+const (
+	day   = 24 * time.Hour
+	month = 30 * day
+	year  = 365*day + 6*time.Hour
+)
+
+// ParseDuration Разбор INTERVAL из текстового вида Postgres: "02:00:00",
+// "1 day 02:00:00", "3 days", "1 mon 2 days -01:00:00"
+func ParseDuration(s string) (time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("invalid duration format")
+	}
+
+	var duration time.Duration
+	if strings.Contains(fields[len(fields)-1], ":") {
+		clock, err := parseClock(fields[len(fields)-1])
+		if err != nil {
+			return 0, err
+		}
+		duration = clock
+		fields = fields[:len(fields)-1]
+	}
+
+	if len(fields)%2 != 0 {
+		return 0, fmt.Errorf("invalid duration format")
+	}
+	for i := 0; i < len(fields); i += 2 {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", fields[i+1], err)
+		}
+		var unit time.Duration
+		switch fields[i+1] {
+		case "day", "days":
+			unit = day
+		case "mon", "mons":
+			unit = month
+		case "year", "years":
+			unit = year
+		default:
+			return 0, fmt.Errorf("invalid duration unit %q", fields[i+1])
+		}
+		duration += time.Duration(n) * unit
+	}
+
+	return duration, nil
+}
+
+// parseClock Разбор части [-]hh:mm:ss[.ffffff]
+func parseClock(s string) (time.Duration, error) {
+	negative := strings.HasPrefix(s, "-")
+	parts := strings.Split(strings.TrimLeft(s, "+-"), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid duration format")
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid hours: %w", err)
+	}
+
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid minutes: %w", err)
+	}
+
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid seconds: %w", err)
+	}
+
+	duration := time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)).Round(time.Microsecond)
+	if negative {
+		duration = -duration
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"00:00:00", 0},
+		{"02:03:04", 2*time.Hour + 3*time.Minute + 4*time.Second},
+		{"00:00:01.5", 1500 * time.Millisecond},
+		{"00:00:00.000001", time.Microsecond},
+		{"25:00:00", 25 * time.Hour},
+		{"1 day", 24 * time.Hour},
+		{"1 day 02:00:00", 26 * time.Hour},
+		{"3 days 00:30:00", 72*time.Hour + 30*time.Minute},
+		{"-01:00:00", -time.Hour},
+		{"1 day -01:00:00", 23 * time.Hour},
+		{"1 mon 2 days", 32 * 24 * time.Hour},
+		{"1 year", 365*24*time.Hour + 6*time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDuration(tt.in)
+			if err != nil {
+				t.Fatalf("ParseDuration(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDurationInvalid(t *testing.T) {
+	for _, in := range []string{"", "02:00", "1 day 2", "1 week 00:00:00", "x:00:00", "00:00:xx"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) succeeded, want an error", in)
+		}
+	}
+}
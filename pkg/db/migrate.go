@@ -0,0 +1,312 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/pkg/logger"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey Ключ advisory lock, чтобы реплики не мигрировали одновременно
+const migrationLockKey = 7310414121
+
+var migrationName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change with its rollback.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration is applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator applies the embedded migrations and records them in
+// schema_migrations.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: unexpected file %s", ErrMigrate, entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(files, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %d has two names", ErrMigrate, version)
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: version %d needs both up and down files", ErrMigrate, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("%w: version %d is missing", ErrMigrate, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func (m *Migrator) Latest() int {
+	return len(m.migrations)
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if current == 0 {
+			return nil
+		}
+		return m.migrate(ctx, conn, current, current-1)
+	})
+}
+
+// To migrates up or down until the schema is at the version.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version < 0 || version > m.Latest() {
+		return fmt.Errorf("%w: unknown version %d", ErrMigrate, version)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.migrate(ctx, conn, current, version)
+	})
+}
+
+// Version returns the version the schema is at, 0 before the first
+// migration. It only reads, so probes may call it without CREATE rights.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	return currentVersion(ctx, m.db)
+}
+
+// Check reports ErrMigrate when the schema is behind the embedded migrations.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return fmt.Errorf("%w: migrations not applied", ErrMigrate)
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("%w: schema is at version %d of %d", ErrMigrate, version, m.Latest())
+	}
+	return nil
+}
+
+// Status lists every embedded migration and when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	exists, err := migrationsTableExists(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time)
+	if exists {
+		if applied, err = appliedMigrations(ctx, m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+func appliedMigrations(ctx context.Context, q DBTX) (map[int]time.Time, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, appliedAt FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, from int, to int) error {
+	for version := from + 1; version <= to; version++ {
+		migration := m.migrations[version-1]
+		logger.FromContext(ctx).WithFields(log.Fields{"version": migration.Version, "name": migration.Name}).Info("migrate up")
+		err := inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations(version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%w: up %d_%s: %s", ErrMigrate, migration.Version, migration.Name, err.Error())
+		}
+	}
+
+	for version := from; version > to; version-- {
+		migration := m.migrations[version-1]
+		logger.FromContext(ctx).WithFields(log.Fields{"version": migration.Version, "name": migration.Name}).Info("migrate down")
+		err := inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%w: down %d_%s: %s", ErrMigrate, migration.Version, migration.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return err
+	}
+	defer func() {
+		// Контекст мог быть отменен, снимаем блокировку в любом случае
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			logger.FromContext(ctx).WithError(err).Error("release migration lock")
+		}
+	}()
+
+	if err = createMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func createMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		appliedAt TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')
+	);
+    `)
+	return err
+}
+
+// migrationsTableExists Проверка без DDL: таблицы нет, пока не было ни одной миграции
+func migrationsTableExists(ctx context.Context, q DBTX) (bool, error) {
+	var exists bool
+	err := q.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists)
+	return exists, err
+}
+
+func currentVersion(ctx context.Context, q DBTX) (int, error) {
+	exists, err := migrationsTableExists(ctx, q)
+	if err != nil || !exists {
+		return 0, err
+	}
+	var version int
+	err = q.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			logger.FromContext(ctx).WithError(rbErr).Error("rollback migration")
+		}
+		return err
+	}
+	return tx.Commit()
+}
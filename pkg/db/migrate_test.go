@@ -0,0 +1,384 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/db/dbtest"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestProbesAreReadOnly checks that Version and Check, called by /readyz
+// and /version, only read: sqlmock fails on any statement not expected.
+func TestProbesAreReadOnly(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	m, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	if version, err := m.Version(ctx); err != nil || version != 0 {
+		t.Fatalf("Version without schema_migrations = %d, %v, want 0", version, err)
+	}
+
+	mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	err = m.Check(ctx)
+	if !errors.Is(err, db.ErrMigrate) || !strings.Contains(err.Error(), "migrations not applied") {
+		t.Fatalf("Check without schema_migrations = %v, want migrations not applied", err)
+	}
+
+	mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT COALESCE").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(m.Latest()))
+	if err = m.Check(ctx); err != nil {
+		t.Fatalf("Check at the latest version: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateUpDownUp(t *testing.T) {
+	conn := dbtest.Open(t)
+	ctx := context.Background()
+	m, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	assertVersion(t, m, m.Latest())
+	if err = m.Check(ctx); err != nil {
+		t.Fatalf("check after up: %v", err)
+	}
+
+	for i := m.Latest(); i > 0; i-- {
+		if err = m.Down(ctx); err != nil {
+			t.Fatalf("down from %d: %v", i, err)
+		}
+		assertVersion(t, m, i-1)
+	}
+	if err = m.Check(ctx); !errors.Is(err, db.ErrMigrate) {
+		t.Fatalf("check at version 0 = %v, want ErrMigrate", err)
+	}
+	for _, table := range []string{"people", "task", "session", "task_interval"} {
+		if exists(t, conn, table) {
+			t.Errorf("table %s is left after rolling everything back", table)
+		}
+	}
+
+	if err = m.Up(ctx); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+	assertVersion(t, m, m.Latest())
+}
+
+// TestMigrateBackfills fills the baseline schema the way older releases
+// did and checks that the interval and status backfills give the same
+// result however many times the migrations run.
+func TestMigrateBackfills(t *testing.T) {
+	conn := dbtest.Open(t)
+	ctx := context.Background()
+	m, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.To(ctx, 1); err != nil {
+		t.Fatalf("to baseline: %v", err)
+	}
+
+	var personId int64
+	err = conn.QueryRowContext(ctx, "INSERT INTO people(name, passportNumber, password) VALUES ('Ivan', '1234 567890', 'hash') RETURNING id").Scan(&personId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO task(name, startTime, endTime, totalTime, personId) VALUES
+		('finished', $1, $2, $3, $4),
+		('running', $2, NULL, NULL, $4)`,
+		start, start.Add(26*time.Hour), "26:00:00", personId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	want := backfilled(t, conn)
+	if len(want.intervals) != 2 {
+		t.Fatalf("got %d intervals, want one per task", len(want.intervals))
+	}
+	if want.statuses["finished"] != "finished" || want.statuses["running"] != "running" {
+		t.Fatalf("statuses = %v", want.statuses)
+	}
+
+	// Повторный Up ничего не делает
+	if err = m.Up(ctx); err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if got := backfilled(t, conn); !reflect.DeepEqual(got, want) {
+		t.Fatalf("second up changed the data:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Откат 0002-0004 и повторное применение дают те же интервалы и статусы
+	if err = m.To(ctx, 1); err != nil {
+		t.Fatalf("back to baseline: %v", err)
+	}
+	if err = m.Up(ctx); err != nil {
+		t.Fatalf("up after rollback: %v", err)
+	}
+	if got := backfilled(t, conn); !reflect.DeepEqual(got, want) {
+		t.Fatalf("backfill is not repeatable:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+// TestMigrateAdoptsTasksArray starts from the schema the repositories
+// created before task.personId: ownership in people.tasks and no foreign
+// key. The baseline moves the arrays into task.personId, drops the column
+// and lets a deleted person take their tasks along.
+func TestMigrateAdoptsTasksArray(t *testing.T) {
+	conn := dbtest.Open(t)
+	ctx := context.Background()
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE people (
+			id SERIAL PRIMARY KEY,
+			name TEXT,
+			surName TEXT,
+			patronymic TEXT,
+			address TEXT,
+			tasks INTEGER[],
+			passportNumber TEXT NOT NULL,
+			password TEXT NOT NULL
+		);
+		CREATE TABLE task (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			description TEXT,
+			startTime TIMESTAMP NOT NULL,
+			endTime TIMESTAMP,
+			totalTime INTERVAL
+		);
+		INSERT INTO task(id, name, startTime) VALUES (1, 'first', now()), (2, 'second', now()), (3, 'third', now()), (4, 'nobody', now());
+		INSERT INTO people(id, name, passportNumber, password, tasks) VALUES
+			(1, 'Ivan', '1234 567890', 'hash', '{1,2,99}'),
+			(2, 'Petr', '4321 098765', 'hash', '{3}');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.To(ctx, 1); err != nil {
+		t.Fatalf("to baseline: %v", err)
+	}
+
+	owners := func() map[string]sql.NullInt64 {
+		t.Helper()
+		rows, err := conn.QueryContext(ctx, "SELECT name, personId FROM task")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		result := make(map[string]sql.NullInt64)
+		for rows.Next() {
+			var name string
+			var personId sql.NullInt64
+			if err = rows.Scan(&name, &personId); err != nil {
+				t.Fatal(err)
+			}
+			result[name] = personId
+		}
+		return result
+	}
+	want := map[string]sql.NullInt64{
+		"first":  {Int64: 1, Valid: true},
+		"second": {Int64: 1, Valid: true},
+		"third":  {Int64: 2, Valid: true},
+		"nobody": {},
+	}
+	if got := owners(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("owners = %v, want %v", got, want)
+	}
+
+	var columns int
+	err = conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'people' AND column_name = 'tasks'").Scan(&columns)
+	if err != nil || columns != 0 {
+		t.Fatalf("people.tasks is still there: %d, %v", columns, err)
+	}
+
+	if _, err = conn.ExecContext(ctx, "DELETE FROM people WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	delete(want, "first")
+	delete(want, "second")
+	if got := owners(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after deleting person 1 owners = %v, want %v", got, want)
+	}
+}
+
+func TestMigrateRollsBackFailedMigration(t *testing.T) {
+	conn := dbtest.Open(t)
+	ctx := context.Background()
+	m := db.NewMigratorWith(conn, []db.Migration{
+		{Version: 1, Name: "first", Up: "CREATE TABLE first (id INTEGER)", Down: "DROP TABLE first"},
+		{Version: 2, Name: "broken", Up: "CREATE TABLE second (id INTEGER); SELECT * FROM missing", Down: "DROP TABLE second"},
+	})
+
+	if err := m.Up(ctx); !errors.Is(err, db.ErrMigrate) {
+		t.Fatalf("up = %v, want ErrMigrate", err)
+	}
+	assertVersion(t, m, 1)
+	if !exists(t, conn, "first") {
+		t.Error("the applied migration was rolled back")
+	}
+	if exists(t, conn, "second") {
+		t.Error("the failed migration left its table behind")
+	}
+}
+
+func TestMigrateConcurrently(t *testing.T) {
+	conn := dbtest.Open(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, err := db.NewMigrator(conn)
+			if err == nil {
+				err = m.Up(ctx)
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("migrator %d: %v", i, err)
+		}
+	}
+
+	m, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied int
+	if err = conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != m.Latest() {
+		t.Fatalf("schema_migrations has %d rows, want %d", applied, m.Latest())
+	}
+}
+
+type snapshot struct {
+	intervals []string
+	statuses  map[string]string
+}
+
+func backfilled(t *testing.T, conn *sql.DB) snapshot {
+	t.Helper()
+	result := snapshot{statuses: make(map[string]string)}
+
+	rows, err := conn.Query(`
+		SELECT task.name, task_interval.startTime, task_interval.endTime
+		FROM task_interval JOIN task ON task.id = task_interval.taskId
+		ORDER BY task.name, task_interval.startTime`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var startTime time.Time
+		var endTime sql.NullTime
+		if err := rows.Scan(&name, &startTime, &endTime); err != nil {
+			t.Fatal(err)
+		}
+		interval := name + " " + startTime.UTC().Format(time.RFC3339) + " - "
+		if endTime.Valid {
+			interval += endTime.Time.UTC().Format(time.RFC3339)
+		}
+		result.intervals = append(result.intervals, interval)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err = conn.Query("SELECT name, status FROM task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, status string
+		if err := rows.Scan(&name, &status); err != nil {
+			t.Fatal(err)
+		}
+		result.statuses[name] = status
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func assertVersion(t *testing.T, m *db.Migrator, want int) {
+	t.Helper()
+	version, err := m.Version(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != want {
+		t.Fatalf("version = %d, want %d", version, want)
+	}
+}
+
+func exists(t *testing.T, conn *sql.DB, table string) bool {
+	t.Helper()
+	var name sql.NullString
+	if err := conn.QueryRow("SELECT to_regclass($1)::TEXT", table).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	return name.Valid
+}
+
+// TestMigrateSchemasApart migrates two schemas of one database. The
+// baseline looks for its column and constraint in the current schema only,
+// so the second schema gets them even though the first already has
+// objects of the same names.
+func TestMigrateSchemasApart(t *testing.T) {
+	ctx := context.Background()
+	for i, conn := range []*sql.DB{dbtest.Migrated(t), dbtest.Migrated(t)} {
+		var count int
+		err := conn.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM pg_constraint WHERE conrelid = 'task'::regclass AND conname = 'task_personid_fkey'",
+		).Scan(&count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("schema %d has %d task_personid_fkey constraints, want 1", i+1, count)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS session;
+DROP TABLE IF EXISTS task;
+DROP TABLE IF EXISTS people;
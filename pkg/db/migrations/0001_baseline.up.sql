@@ -0,0 +1,64 @@
+-- Baseline: the schema that repositories created at startup before
+-- versioned migrations. Statements are idempotent so that databases created
+-- by older releases are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS people (
+	id SERIAL PRIMARY KEY,
+	name TEXT ,
+	surName TEXT ,
+	patronymic TEXT ,
+	address TEXT ,
+	passportNumber TEXT NOT NULL,
+	password TEXT NOT NULL,
+	role TEXT NOT NULL DEFAULT 'employee' CHECK (role IN ('admin', 'manager', 'employee')),
+	managerId INTEGER REFERENCES people(id) ON DELETE SET NULL
+);
+ALTER TABLE people ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'employee' CHECK (role IN ('admin', 'manager', 'employee'));
+ALTER TABLE people ADD COLUMN IF NOT EXISTS managerId INTEGER REFERENCES people(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS people_managerid_idx ON people(managerId);
+
+CREATE TABLE IF NOT EXISTS task (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	description TEXT,
+	startTime TIMESTAMP NOT NULL,
+	endTime TIMESTAMP,
+	totalTime INTERVAL,
+	personId INTEGER REFERENCES people(id) ON DELETE CASCADE
+);
+ALTER TABLE task ADD COLUMN IF NOT EXISTS personId INTEGER;
+
+-- Move ownership out of the legacy people.tasks array
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'people' AND column_name = 'tasks') THEN
+		UPDATE task SET personId = people.id
+		FROM people
+		WHERE task.personId IS NULL AND task.id = ANY(people.tasks);
+		ALTER TABLE people DROP COLUMN tasks;
+	END IF;
+END $$;
+
+-- Tasks of already deleted people can not satisfy the foreign key
+DELETE FROM task WHERE personId IS NOT NULL AND personId NOT IN (SELECT id FROM people);
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'task'::regclass AND conname = 'task_personid_fkey') THEN
+		ALTER TABLE task ADD CONSTRAINT task_personid_fkey FOREIGN KEY (personId) REFERENCES people(id) ON DELETE CASCADE;
+	END IF;
+END $$;
+CREATE INDEX IF NOT EXISTS task_personid_idx ON task(personId);
+
+CREATE TABLE IF NOT EXISTS session (
+	id TEXT PRIMARY KEY,
+	personId INTEGER NOT NULL REFERENCES people(id) ON DELETE CASCADE,
+	familyId TEXT NOT NULL,
+	refreshHash TEXT NOT NULL UNIQUE,
+	userAgent TEXT,
+	createdAt TIMESTAMP NOT NULL,
+	expiresAt TIMESTAMP NOT NULL,
+	rotatedAt TIMESTAMP,
+	revokedAt TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS session_familyid_idx ON session(familyId);
+CREATE INDEX IF NOT EXISTS session_personid_idx ON session(personId);
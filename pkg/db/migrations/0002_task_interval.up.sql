@@ -0,0 +1,17 @@
+-- Work on a task is a series of intervals between start or resume and
+-- pause or finish. An interval without endTime is the running one.
+
+CREATE TABLE task_interval (
+	id SERIAL PRIMARY KEY,
+	taskId INTEGER NOT NULL REFERENCES task(id) ON DELETE CASCADE,
+	startTime TIMESTAMP NOT NULL,
+	endTime TIMESTAMP,
+	CHECK (endTime IS NULL OR endTime >= startTime)
+);
+CREATE INDEX task_interval_taskid_idx ON task_interval(taskId);
+-- A task has at most one running interval
+CREATE UNIQUE INDEX task_interval_running_idx ON task_interval(taskId) WHERE endTime IS NULL;
+
+-- Tasks started before pauses existed were worked in one go
+INSERT INTO task_interval(taskId, startTime, endTime)
+SELECT id, startTime, endTime FROM task;
@@ -0,0 +1,4 @@
+-- Per person policy: at most one running timer across the tasks of the
+-- person. Timer changes lock the people row, so the rule holds under
+-- concurrent requests.
+ALTER TABLE people ADD COLUMN singleTimer BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,12 @@
+-- Explicit task status instead of guessing it from endTime and intervals
+ALTER TABLE task ADD COLUMN status TEXT NOT NULL DEFAULT 'running'
+	CHECK (status IN ('planned', 'running', 'paused', 'finished', 'cancelled'));
+
+UPDATE task SET status = CASE
+	WHEN endTime IS NOT NULL THEN 'finished'
+	WHEN EXISTS (SELECT 1 FROM task_interval WHERE task_interval.taskId = task.id AND task_interval.endTime IS NULL) THEN 'running'
+	ELSE 'paused'
+END;
+
+ALTER TABLE task ALTER COLUMN status DROP DEFAULT;
+CREATE INDEX task_personid_status_idx ON task(personId, status);
@@ -0,0 +1,14 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX Общий интерфейс *sql.DB и *sql.Tx, чтобы репозитории работали
+// одинаково внутри транзакции и вне ее
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
@@ -0,0 +1,107 @@
+// Package di builds the application from the config. The graph is wired by
+// hand: besides constructors it waits for the database, applies the
+// migrations, registers metrics and hands the resources to close to the
+// server, which a generated injector would not do.
+package di
+
+import (
+	"context"
+	"database/sql"
+	http "effectiveMobile/pkg/api"
+	"effectiveMobile/pkg/api/handler"
+	"effectiveMobile/pkg/config"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/metrics"
+	"effectiveMobile/pkg/repo"
+	"effectiveMobile/pkg/repo/people"
+	"effectiveMobile/pkg/repo/session"
+	"effectiveMobile/pkg/repo/task"
+	"effectiveMobile/pkg/service"
+	"effectiveMobile/pkg/token"
+	"effectiveMobile/pkg/tracing"
+	"time"
+)
+
+func InitializeAPI(cfg config.Config) (*http.ServerHTTP, error) {
+	ctx := context.Background()
+	tracer, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	bd, err := db.ConnectToBD(ctx, cfg)
+	if err != nil {
+		tracer.Close()
+		return nil, err
+	}
+	serverHTTP, err := initializeAPI(cfg, bd)
+	if err != nil {
+		bd.Close()
+		tracer.Close()
+		return nil, err
+	}
+	// Закрываются в обратном порядке после остановки сервера:
+	// сначала пул соединений, потом выгрузка оставшихся span'ов
+	serverHTTP.AddCloser(tracer)
+	serverHTTP.AddCloser(bd)
+
+	return serverHTTP, nil
+}
+
+func initializeAPI(cfg config.Config, bd *sql.DB) (*http.ServerHTTP, error) {
+
+	// Не принимаем запросы, пока база недоступна
+	err := db.WaitForDB(context.Background(), bd, cfg.PsqlConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Init Migrate
+	migrator, err := db.NewMigrator(bd)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	err = migrator.Up(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Гейдж показывает версию, записанную в базе, как и /version
+	applied, err := migrator.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metrics.SchemaVersion.Set(float64(applied))
+
+	// Repository
+	peopleRepository := people.NewPeopleDataBase(bd)
+	taskRepository := task.NewTaskDataBase(bd)
+	sessionRepository := session.NewSessionDataBase(bd)
+	unitOfWork := repo.NewUnitOfWork(bd)
+
+	// Metrics
+	if err = metrics.RegisterDB(bd, cfg.PsqlDBName); err != nil {
+		return nil, err
+	}
+	err = metrics.RegisterRunningTimers(func() (int64, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		return taskRepository.CountRunning(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//service - logic
+	userService := service.NewService(cfg, unitOfWork, peopleRepository, taskRepository, sessionRepository)
+
+	tokenManager, err := token.NewManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	userHandler := handler.NewHandler(cfg, userService, tokenManager)
+	probe := handler.NewProbe(bd, migrator)
+	serverHTTP := http.NewServerHTTP(cfg, userHandler, probe)
+
+	return serverHTTP, nil
+}
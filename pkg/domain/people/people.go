@@ -2,8 +2,8 @@ package people
 
 import (
 	"effectiveMobile/pkg/domain/task"
-	"fmt"
 	"github.com/go-playground/validator/v10"
+	"reflect"
 	"regexp"
 	"strings"
 )
@@ -55,24 +55,14 @@ type Info struct {
 	Address    string `json:"address"  validate:"latin-cyrillic"`
 }
 
-// Validate validates the Info struct.
+// Validate validates the Info struct. The error lists every failed field
+// as validator.ValidationErrors.
 func (info *Info) Validate() error {
-	validate := validator.New()
-
-	if err := validate.RegisterValidation("latin-cyrillic", validateLatinCyrillic); err != nil {
-		return err
-	}
-	err := validate.Struct(info)
+	validate, err := newValidator()
 	if err != nil {
-		// Handle validation errors
-		var validationErrors []string
-		for _, err := range err.(validator.ValidationErrors) {
-			validationErrors = append(validationErrors, err.Error())
-		}
-		return fmt.Errorf("info validation errors: %s", strings.Join(validationErrors, ", "))
+		return err
 	}
-
-	return nil // No validation errors
+	return validate.Struct(info)
 }
 
 // Custom validation function for Latin and Cyrillic characters
@@ -90,22 +80,11 @@ type Registration struct {
 }
 
 func (r *Registration) Validate() error {
-	validate := validator.New()
-
-	if err := validate.RegisterValidation("passportValidate", validatePassportNumber); err != nil {
-		return err
-	}
-	err := validate.Struct(r)
+	validate, err := newValidator()
 	if err != nil {
-		// Handle validation errors
-		var validationErrors []string
-		for _, err := range err.(validator.ValidationErrors) {
-			validationErrors = append(validationErrors, err.Error())
-		}
-		return fmt.Errorf("info validation errors: %s", strings.Join(validationErrors, ", "))
+		return err
 	}
-
-	return nil // No validation errors
+	return validate.Struct(r)
 }
 
 func validatePassportNumber(fl validator.FieldLevel) bool {
@@ -114,9 +93,88 @@ func validatePassportNumber(fl validator.FieldLevel) bool {
 	return regex.MatchString(fl.Field().String())
 }
 
+// MaskPassport hides every digit of the passport except the last three,
+// "1234 567123" becomes "**** ***123".
+func MaskPassport(passport string) string {
+	masked := []rune(passport)
+	visible := 3
+	for i := len(masked) - 1; i >= 0; i-- {
+		if masked[i] < '0' || masked[i] > '9' {
+			continue
+		}
+		if visible > 0 {
+			visible--
+			continue
+		}
+		masked[i] = '*'
+	}
+	return string(masked)
+}
+
 // Pagination represents pagination parameters.
 // @swagger:model
 type Pagination struct {
 	Limit  int `form:"limit" binding:"omitempty,min=1"`
 	Offset int `form:"offset" binding:"omitempty,min=1"`
 }
+
+// Role represents the access level of a person.
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleManager  Role = "manager"
+	RoleEmployee Role = "employee"
+)
+
+// Access represents the role of a person and the manager they report to.
+// @swagger:model
+type Access struct {
+	ID        int64  `json:"id"`
+	Role      Role   `json:"role" validate:"required,oneof=admin manager employee"`
+	ManagerID *int64 `json:"managerId"`
+}
+
+func (a *Access) Validate() error {
+	validate, err := newValidator()
+	if err != nil {
+		return err
+	}
+	return validate.Struct(a)
+}
+
+// TimerPolicy tells whether a person may run only one task timer at a time.
+// @swagger:model
+type TimerPolicy struct {
+	ID          int64 `json:"id"`
+	SingleTimer *bool `json:"singleTimer" validate:"required"`
+}
+
+func (p *TimerPolicy) Validate() error {
+	validate, err := newValidator()
+	if err != nil {
+		return err
+	}
+	return validate.Struct(p)
+}
+
+// newValidator returns a validator with the custom rules of the package that
+// reports fields under their json names.
+func newValidator() (*validator.Validate, error) {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+
+	if err := validate.RegisterValidation("latin-cyrillic", validateLatinCyrillic); err != nil {
+		return nil, err
+	}
+	if err := validate.RegisterValidation("passportValidate", validatePassportNumber); err != nil {
+		return nil, err
+	}
+	return validate, nil
+}
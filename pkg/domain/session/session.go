@@ -0,0 +1,23 @@
+package session
+
+import (
+	"effectiveMobile/pkg/domain/people"
+	"time"
+)
+
+// Session represents a login of a person. Every refresh rotates the session
+// into a new one of the same family.
+// @swagger:model
+type Session struct {
+	ID        string     `json:"id"`
+	PersonID  int64      `json:"personId"`
+	FamilyID  string     `json:"familyId"`
+	UserAgent string     `json:"userAgent"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RotatedAt *time.Time `json:"-"`
+	RevokedAt *time.Time `json:"-"`
+
+	// Role of the person when the session was issued, not stored
+	Role people.Role `json:"-"`
+}
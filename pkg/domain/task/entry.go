@@ -0,0 +1,67 @@
+package task
+
+import (
+	"fmt"
+	"time"
+)
+
+// Entry is work recorded by hand after the fact, for a person who forgot
+// to start the timer.
+// @swagger:model
+type Entry struct {
+	Name        string    `json:"name" binding:"required"`
+	Description string    `json:"description"`
+	StartTime   time.Time `json:"startTime" binding:"required"`
+	EndTime     time.Time `json:"endTime" binding:"required"`
+}
+
+// Patch corrects a task, nil fields stay as they are. StartTime moves the
+// start of the first interval, EndTime the end of the last one.
+// @swagger:model
+type Patch struct {
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	StartTime   *time.Time `json:"startTime"`
+	EndTime     *time.Time `json:"endTime"`
+}
+
+// FieldError tells which field of a task, an entry or a correction is
+// wrong.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Detail()
+}
+
+// Detail is the message shown to the client.
+func (e *FieldError) Detail() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// CheckIntervals makes sure every interval ends after it starts, none ends
+// after now and they follow each other without overlapping.
+func CheckIntervals(intervals []Interval, now time.Time) error {
+	for i, interval := range intervals {
+		if interval.StartTime.After(now) {
+			return &FieldError{Field: "startTime", Message: "is in the future"}
+		}
+		if interval.EndTime != nil {
+			if !interval.EndTime.After(interval.StartTime) {
+				return &FieldError{Field: "endTime", Message: "must be after startTime"}
+			}
+			if interval.EndTime.After(now) {
+				return &FieldError{Field: "endTime", Message: "is in the future"}
+			}
+		}
+		if i > 0 {
+			prev := intervals[i-1]
+			if prev.EndTime == nil || prev.EndTime.After(interval.StartTime) {
+				return &FieldError{Field: "startTime", Message: "overlaps a pause of the task"}
+			}
+		}
+	}
+	return nil
+}
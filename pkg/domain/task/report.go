@@ -0,0 +1,63 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Report is the labor cost of a person over a period: the tasks worked on,
+// from the most time spent to the least, and the total.
+// @swagger:model
+type Report struct {
+	PersonID  int64        `json:"personId"`
+	StartTime time.Time    `json:"startTime"`
+	EndTime   time.Time    `json:"endTime"`
+	Tasks     []ReportLine `json:"tasks"`
+	Total     Spent        `json:"total"`
+}
+
+// ReportLine is the time spent on one task inside the period.
+// @swagger:model
+type ReportLine struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Status Status `json:"status" enums:"planned,running,paused,finished,cancelled"`
+	Spent  Spent  `json:"spent"`
+}
+
+// Spent is a duration split into hours and minutes, Time is the same as
+// text like "12h 05m".
+// @swagger:model
+type Spent struct {
+	Hours   int64  `json:"hours"`
+	Minutes int64  `json:"minutes"`
+	Time    string `json:"time"`
+}
+
+// NewSpent rounds the duration down to whole minutes.
+func NewSpent(d time.Duration) Spent {
+	minutes := int64(d / time.Minute)
+	s := Spent{Hours: minutes / 60, Minutes: minutes % 60}
+	s.Time = fmt.Sprintf("%dh %02dm", s.Hours, s.Minutes)
+	return s
+}
+
+// NewReport sorts the tasks by time spent and sums it up. The total is
+// summed before rounding, so it may exceed the sum of the lines.
+func NewReport(personId int64, startTime time.Time, endTime time.Time, tasks Slice) Report {
+	sort.Sort(tasks)
+
+	report := Report{PersonID: personId, StartTime: startTime, EndTime: endTime, Tasks: make([]ReportLine, 0, len(tasks))}
+	var total time.Duration
+	for _, t := range tasks {
+		var spent time.Duration
+		if t.TotalTime != nil {
+			spent = *t.TotalTime
+		}
+		total += spent
+		report.Tasks = append(report.Tasks, ReportLine{ID: t.ID, Name: t.Name, Status: t.Status, Spent: NewSpent(spent)})
+	}
+	report.Total = NewSpent(total)
+	return report
+}
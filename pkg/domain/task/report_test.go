@@ -0,0 +1,61 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSpent(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want Spent
+	}{
+		{0, Spent{Hours: 0, Minutes: 0, Time: "0h 00m"}},
+		{59 * time.Second, Spent{Hours: 0, Minutes: 0, Time: "0h 00m"}},
+		{59 * time.Minute, Spent{Hours: 0, Minutes: 59, Time: "0h 59m"}},
+		{59*time.Minute + 59*time.Second, Spent{Hours: 0, Minutes: 59, Time: "0h 59m"}},
+		{time.Hour, Spent{Hours: 1, Minutes: 0, Time: "1h 00m"}},
+		{26*time.Hour + 5*time.Minute, Spent{Hours: 26, Minutes: 5, Time: "26h 05m"}},
+		{100 * time.Hour, Spent{Hours: 100, Minutes: 0, Time: "100h 00m"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in.String(), func(t *testing.T) {
+			if got := NewSpent(tt.in); got != tt.want {
+				t.Errorf("NewSpent(%v) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	duration := func(d time.Duration) *time.Duration { return &d }
+	tasks := Slice{
+		{ID: 4, Name: "none"},
+		{ID: 3, Name: "short", TotalTime: duration(30 * time.Second)},
+		{ID: 2, Name: "tie b", TotalTime: duration(time.Hour + 30*time.Second)},
+		{ID: 5, Name: "long", TotalTime: duration(25 * time.Hour)},
+		{ID: 1, Name: "tie a", TotalTime: duration(time.Hour + 30*time.Second)},
+	}
+
+	report := NewReport(7, time.Time{}, time.Time{}, tasks)
+
+	wantIds := []int64{5, 1, 2, 3, 4}
+	if len(report.Tasks) != len(wantIds) {
+		t.Fatalf("got %d lines, want %d", len(report.Tasks), len(wantIds))
+	}
+	for i, id := range wantIds {
+		if report.Tasks[i].ID != id {
+			t.Errorf("line %d is task %d, want %d", i, report.Tasks[i].ID, id)
+		}
+	}
+	if report.Tasks[4].Spent.Time != "0h 00m" {
+		t.Errorf("task without time spent %q, want 0h 00m", report.Tasks[4].Spent.Time)
+	}
+	// Сумма до округления: 30s + 2*(1h 30s) + 25h = 27h 01m 30s
+	if report.Total.Time != "27h 01m" {
+		t.Errorf("total = %q, want 27h 01m", report.Total.Time)
+	}
+	if report.PersonID != 7 {
+		t.Errorf("personId = %d, want 7", report.PersonID)
+	}
+}
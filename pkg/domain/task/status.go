@@ -0,0 +1,85 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the stage of a task. A task is planned or started running,
+// pauses and resumes any number of times and ends finished or cancelled.
+type Status string
+
+const (
+	StatusPlanned   Status = "planned"
+	StatusRunning   Status = "running"
+	StatusPaused    Status = "paused"
+	StatusFinished  Status = "finished"
+	StatusCancelled Status = "cancelled"
+)
+
+// transitions Разрешенные переходы, из finished и cancelled выхода нет
+var transitions = map[Status][]Status{
+	StatusPlanned: {StatusRunning, StatusCancelled},
+	StatusRunning: {StatusPaused, StatusFinished, StatusCancelled},
+	StatusPaused:  {StatusRunning, StatusFinished, StatusCancelled},
+}
+
+// CanBecome reports whether a task in status s may move to next.
+func (s Status) CanBecome(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Closed reports whether the task is finished or cancelled.
+func (s Status) Closed() bool {
+	return s == StatusFinished || s == StatusCancelled
+}
+
+// Valid reports whether s is one of the known statuses.
+func (s Status) Valid() bool {
+	switch s {
+	case StatusPlanned, StatusRunning, StatusPaused, StatusFinished, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// ParseStatuses reads a comma separated list like "running,paused". An
+// empty string returns nil, which filters nothing.
+func ParseStatuses(s string) ([]Status, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var result []Status
+	for _, part := range strings.Split(s, ",") {
+		status := Status(strings.TrimSpace(part))
+		if !status.Valid() {
+			return nil, fmt.Errorf("unknown status %q, expected planned, running, paused, finished or cancelled", part)
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+// TransitionError tells which status change of a task was refused.
+type TransitionError struct {
+	TaskID int64
+	From   Status
+	To     Status
+}
+
+func (e *TransitionError) Error() string {
+	return e.Detail()
+}
+
+// Detail is the message shown to the client.
+func (e *TransitionError) Detail() string {
+	if len(transitions[e.From]) == 0 {
+		return fmt.Sprintf("task %d is %s and can not change anymore", e.TaskID, e.From)
+	}
+	return fmt.Sprintf("task %d is %s and can not become %s", e.TaskID, e.From, e.To)
+}
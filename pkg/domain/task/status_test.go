@@ -0,0 +1,48 @@
+package task
+
+import "testing"
+
+var statuses = []Status{StatusPlanned, StatusRunning, StatusPaused, StatusFinished, StatusCancelled}
+
+// TestCanBecome checks every from→to pair against the state machine as it
+// is documented on Status, so a change of transitions shows up here.
+func TestCanBecome(t *testing.T) {
+	allowed := map[[2]Status]bool{
+		{StatusPlanned, StatusRunning}:   true,
+		{StatusPlanned, StatusCancelled}: true,
+		{StatusRunning, StatusPaused}:    true,
+		{StatusRunning, StatusFinished}:  true,
+		{StatusRunning, StatusCancelled}: true,
+		{StatusPaused, StatusRunning}:    true,
+		{StatusPaused, StatusFinished}:   true,
+		{StatusPaused, StatusCancelled}:  true,
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]Status{from, to}]
+			if got := from.CanBecome(to); got != want {
+				t.Errorf("%s.CanBecome(%s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestParseStatuses(t *testing.T) {
+	got, err := ParseStatuses(" running, paused ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != StatusRunning || got[1] != StatusPaused {
+		t.Errorf("ParseStatuses = %v, want [running paused]", got)
+	}
+
+	if got, err = ParseStatuses(""); err != nil || got != nil {
+		t.Errorf("ParseStatuses(\"\") = %v, %v, want nil, nil", got, err)
+	}
+
+	for _, s := range []string{"done", "running,", "Running", "running,stopped"} {
+		if _, err := ParseStatuses(s); err == nil {
+			t.Errorf("ParseStatuses(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -13,6 +13,49 @@ type Task struct {
 	StartTime   time.Time      `json:"startTime" validate:"required"`
 	EndTime     *time.Time     `json:"endTime"`
 	TotalTime   *time.Duration `json:"totalTime"`
+	PersonID    int64          `json:"personId"`
+	Status      Status         `json:"status" enums:"planned,running,paused,finished,cancelled"`
+	Intervals   []Interval     `json:"intervals,omitempty"`
+}
+
+// OnRunning tells what starting or resuming a task does with the running
+// task of a person whose timer policy allows a single timer.
+type OnRunning string
+
+const (
+	// OnRunningReject refuses to start a second timer, the default
+	OnRunningReject OnRunning = "reject"
+	OnRunningPause  OnRunning = "pause"
+	OnRunningFinish OnRunning = "finish"
+)
+
+// Interval is one stretch of work on a task, from start or resume to pause
+// or finish. EndTime is nil while the interval runs.
+// @swagger:model
+type Interval struct {
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime"`
+}
+
+// Running reports whether the task has a running interval.
+func (t Task) Running() bool {
+	for _, interval := range t.Intervals {
+		if interval.EndTime == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Worked sums the closed intervals of the task.
+func (t Task) Worked() time.Duration {
+	var total time.Duration
+	for _, interval := range t.Intervals {
+		if interval.EndTime != nil {
+			total += interval.EndTime.Sub(interval.StartTime)
+		}
+	}
+	return total
 }
 
 type Slice []Task
@@ -27,6 +70,9 @@ func (s Slice) Less(i, j int) bool {
 	if s[j].TotalTime == nil {
 		return true
 	}
-	// Сортировка от большего к меньшему
-	return *s[i].TotalTime > *s[j].TotalTime
+	// Сортировка от большего к меньшему, при равенстве по ID, чтобы порядок не скакал
+	if *s[i].TotalTime != *s[j].TotalTime {
+		return *s[i].TotalTime > *s[j].TotalTime
+	}
+	return s[i].ID < s[j].ID
 }
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries the request id in and out.
+const RequestIDHeader = "X-Request-ID"
+
+// Входящий id принимаем только безопасного вида, иначе генерируем свой
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9\-_.]{1,64}$`)
+
+// GinMiddleware gives the request an id, taken from X-Request-ID or
+// generated, puts it into the request context and the response header,
+// and writes one access log line when the request is done.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestId := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestId) {
+			requestId = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestId)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), requestId))
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		FromContext(c.Request.Context()).WithFields(log.Fields{
+			"method":     c.Request.Method,
+			"route":      route,
+			"path":       c.Request.URL.Path,
+			"status":     c.Writer.Status(),
+			"latency_ms": time.Since(start).Milliseconds(),
+			"client_ip":  c.ClientIP(),
+			"user_agent": c.Request.UserAgent(),
+		}).Info("request")
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
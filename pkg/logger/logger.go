@@ -0,0 +1,93 @@
+// Package logger configures the one structured logger every layer writes
+// to and carries request scoped fields in the context.
+package logger
+
+import (
+	"context"
+	"fmt"
+	stdlog "log"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats accepted by LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type requestIDKey struct{}
+
+// Setup configures the logrus standard logger, so package level logrus
+// calls and the stdlib log package end up in the same structured output.
+func Setup(format string, level string) error {
+	var formatter log.Formatter
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		formatter = &log.JSONFormatter{}
+	case FormatText:
+		formatter = &log.TextFormatter{FullTimestamp: true}
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	std := log.StandardLogger()
+	std.SetOutput(os.Stdout)
+	std.SetFormatter(&redactFormatter{next: formatter})
+	if err := SetLevel(level); err != nil {
+		return err
+	}
+
+	// Сторонние библиотеки пишут через стандартный log
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(std.WriterLevel(log.InfoLevel))
+	return nil
+}
+
+// SetLevel changes the level of the running logger.
+func SetLevel(level string) error {
+	if level == "" {
+		level = log.InfoLevel.String()
+	}
+	parsed, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(parsed)
+	return nil
+}
+
+// Level returns the current level, such as "info".
+func Level() string {
+	return log.GetLevel().String()
+}
+
+// WithRequestID returns a context carrying the request id.
+func WithRequestID(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestId)
+}
+
+// RequestID returns the request id of the context or "".
+func RequestID(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIDKey{}).(string)
+	return requestId
+}
+
+// FromContext returns the logger of the request: every entry carries the
+// request id and the trace and span ids of the context.
+func FromContext(ctx context.Context) *log.Entry {
+	entry := log.WithContext(ctx)
+	if requestId := RequestID(ctx); requestId != "" {
+		entry = entry.WithField("request_id", requestId)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		entry = entry.WithFields(log.Fields{
+			"trace_id": span.TraceID().String(),
+			"span_id":  span.SpanID().String(),
+		})
+	}
+	return entry
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveFields Значения этих полей не попадают в лог никогда
+var sensitiveFields = map[string]bool{
+	"password":       true,
+	"passport":       true,
+	"passportnumber": true,
+	"passportserie":  true,
+	"token":          true,
+	"refreshtoken":   true,
+	"authorization":  true,
+	"cookie":         true,
+}
+
+var (
+	passportPattern = regexp.MustCompile(`\b\d{4} \d{3}(\d{3})\b`)
+	bearerPattern   = regexp.MustCompile(`(?i)\bbearer\s+\S+`)
+	jwtPattern      = regexp.MustCompile(`\beyJ[\w-]+\.[\w-]+\.[\w-]+`)
+	urlPassPattern  = regexp.MustCompile(`(://[^:/@\s]+):[^@\s]+@`)
+	kvPassPattern   = regexp.MustCompile(`(?i)\b(password|passwd)=('[^']*'|\S+)`)
+)
+
+// redactFormatter masks personal data and secrets before the entry is
+// formatted: sensitive fields by name and passports, tokens and URL
+// passwords anywhere in the message or in string fields.
+type redactFormatter struct {
+	next log.Formatter
+}
+
+func (f *redactFormatter) Format(entry *log.Entry) ([]byte, error) {
+	data := make(log.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		data[key] = redactField(key, value)
+	}
+
+	clean := *entry
+	clean.Data = data
+	clean.Message = Redact(entry.Message)
+	return f.next.Format(&clean)
+}
+
+func redactField(key string, value interface{}) interface{} {
+	if sensitiveFields[strings.ToLower(key)] {
+		return redacted
+	}
+	switch v := value.(type) {
+	case string:
+		return Redact(v)
+	case error:
+		return Redact(v.Error())
+	case fmt.Stringer:
+		return Redact(v.String())
+	default:
+		return value
+	}
+}
+
+// Redact masks passports, bearer tokens, JWTs and passwords of URL and
+// key=value connection strings in s.
+func Redact(s string) string {
+	s = passportPattern.ReplaceAllString(s, "**** ***$1")
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = urlPassPattern.ReplaceAllString(s, "$1:"+redacted+"@")
+	s = kvPassPattern.ReplaceAllString(s, "$1="+redacted)
+	return s
+}
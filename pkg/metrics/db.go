@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+)
+
+// ObserveQuery records the latency and the outcome of a repository query.
+func ObserveQuery(repository string, query string, start time.Time, err error) {
+	operation := Operation(query)
+	queryDuration.WithLabelValues(repository, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		queryErrors.WithLabelValues(repository, operation).Inc()
+	}
+}
+
+// Operation returns the SQL verb of the query in lower case, "select" for
+// "SELECT id FROM task".
+func Operation(query string) string {
+	verb, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	verb = strings.ToLower(strings.TrimSpace(verb))
+	switch verb {
+	case "select", "insert", "update", "delete", "with":
+		return verb
+	default:
+		return "other"
+	}
+}
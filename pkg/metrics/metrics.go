@@ -0,0 +1,138 @@
+// Package metrics exposes the Prometheus metrics of the service.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "effective_mobile"
+
+// HTTP
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Database
+var (
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Repository query latency by repository and SQL operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "operation"})
+
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Failed repository queries by repository and SQL operation.",
+	}, []string{"repository", "operation"})
+
+	SchemaVersion = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "schema_version",
+		Help:      "Version of the last migration applied to the database.",
+	})
+)
+
+// Business events
+var (
+	TasksStarted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_started_total",
+		Help:      "Tasks started.",
+	})
+
+	TasksFinished = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_finished_total",
+		Help:      "Tasks finished.",
+	})
+
+	TasksPaused = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_paused_total",
+		Help:      "Tasks paused.",
+	})
+
+	TasksResumed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_resumed_total",
+		Help:      "Paused tasks resumed.",
+	})
+
+	Registrations = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "People registered.",
+	})
+
+	LoginFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Logins rejected for an unknown passport number or a wrong password.",
+	})
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDB exports the connection pool statistics of the database.
+func RegisterDB(db *sql.DB, dbName string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// RegisterRunningTimers exports the number of running, not paused, tasks. count is
+// called on every scrape, so the value is right across replicas and
+// restarts.
+func RegisterRunningTimers(count func() (int64, error)) error {
+	return prometheus.Register(newRunningTimers(count))
+}
+
+func newRunningTimers(count func() (int64, error)) *runningTimers {
+	return &runningTimers{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "running_timers"),
+			"Tasks with a running timer, paused and finished ones excluded.",
+			nil, nil,
+		),
+		count: count,
+	}
+}
+
+type runningTimers struct {
+	desc  *prometheus.Desc
+	count func() (int64, error)
+}
+
+func (r *runningTimers) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.desc
+}
+
+func (r *runningTimers) Collect(ch chan<- prometheus.Metric) {
+	count, err := r.count()
+	if err != nil {
+		// Лучше пропуск в графике, чем ложный ноль
+		ch <- prometheus.NewInvalidMetric(r.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(r.desc, prometheus.GaugeValue, float64(count))
+}
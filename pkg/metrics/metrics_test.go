@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGinMiddlewareLabelsRouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	httpRequests.Reset()
+
+	engine := gin.New()
+	engine.Use(GinMiddleware())
+	engine.GET("/people/:personId", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/people/42", "/people/43", "/missing/1", "/missing/2"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	want := `
+# HELP effective_mobile_http_requests_total HTTP requests by method, route template and status.
+# TYPE effective_mobile_http_requests_total counter
+effective_mobile_http_requests_total{method="GET",route="/people/:personId",status="200"} 2
+effective_mobile_http_requests_total{method="GET",route="unmatched",status="404"} 2
+`
+	if err := testutil.CollectAndCompare(httpRequests, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunningTimers(t *testing.T) {
+	want := `
+# HELP effective_mobile_running_timers Tasks with a running timer, paused and finished ones excluded.
+# TYPE effective_mobile_running_timers gauge
+effective_mobile_running_timers 3
+`
+	collector := newRunningTimers(func() (int64, error) { return 3, nil })
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunningTimersFailedCount(t *testing.T) {
+	// Ошибка счетчика дает невалидную метрику, а не ноль
+	collector := newRunningTimers(func() (int64, error) { return 0, errors.New("database is down") })
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+
+	_, err := registry.Gather()
+	if err == nil || !strings.Contains(err.Error(), "database is down") {
+		t.Fatalf("Gather = %v, want the count error", err)
+	}
+}
@@ -3,16 +3,19 @@ package interfaces
 import (
 	"context"
 	people "effectiveMobile/pkg/domain/people"
-	"effectiveMobile/pkg/domain/task"
 )
 
 type PeopleRepository interface {
-	Migrate(ctx context.Context) error
 	Info(ctx context.Context, passportNumber string) (*people.Info, error)
 	Registration(ctx context.Context, newPeople people.Registration) (*int64, error)
-	Login(ctx context.Context, acc people.Registration) (int64, error)
-	Get(ctx context.Context, filter *people.Filter, pagination *people.Pagination) ([]people.Request, error)
+	Login(ctx context.Context, passportNumber string) (int64, string, error)
+	UpdatePassword(ctx context.Context, id int64, password string) error
+	GetAccess(ctx context.Context, id int64) (*people.Access, error)
+	PutAccess(ctx context.Context, id int64, access people.Access) (*people.Access, error)
+	LockTimer(ctx context.Context, id int64) (bool, error)
+	PutTimerPolicy(ctx context.Context, id int64, singleTimer bool) error
+	GetReports(ctx context.Context, managerId int64) ([]int64, error)
+	Get(ctx context.Context, personIds []int64, filter *people.Filter, pagination *people.Pagination) ([]people.Request, error)
 	Put(ctx context.Context, id int64, updatePeople people.Info) (*people.Info, error)
 	Delete(ctx context.Context, id int64) error
-	AppendTask(ctx context.Context, id int64, task task.Task) error
 }
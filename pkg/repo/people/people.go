@@ -10,54 +10,26 @@ import (
 	"errors"
 	"fmt"
 	"github.com/lib/pq"
-	"log"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/jackc/pgconn"
 )
 
 type accountDataBase struct {
-	db *sql.DB
+	db db.DBTX
 }
 
-func NewPeopleDataBase(db *sql.DB) interfaces.PeopleRepository {
+func NewPeopleDataBase(conn db.DBTX) interfaces.PeopleRepository {
 	return &accountDataBase{
-		db: db,
+		db: db.Instrument(conn, "people"),
 	}
 }
 
-func (r *accountDataBase) Migrate(ctx context.Context) error {
-	accQuery := `
-    CREATE TABLE IF NOT EXISTS people (
-		id SERIAL PRIMARY KEY,
-		name TEXT ,
-		surName TEXT ,
-		patronymic TEXT ,
-		address TEXT ,
-		tasks INTEGER[],
-		passportNumber TEXT NOT NULL,
-		password TEXT NOT NULL
-	);
-    `
-	_, err := r.db.ExecContext(ctx, accQuery)
-	if err != nil {
-		message := db.ErrMigrate.Error() + " people"
-		log.Printf("%q: %s\n", message, err.Error())
-		return db.ErrMigrate
-	}
-
-	return err
-}
-
 func (r *accountDataBase) Info(ctx context.Context, passportNumber string) (*people.Info, error) {
-	row := r.db.QueryRowContext(ctx, "SELECT * FROM people WHERE passportNumber = $1", passportNumber)
+	row := r.db.QueryRowContext(ctx, "SELECT id, name, surname, patronymic, address FROM people WHERE passportNumber = $1", passportNumber)
 
 	var result people.Info
 	var nameNull, surnameNull, patronymicNull, addressNull sql.NullString
-	var tasksArray sql.NullString
-	var password *string
 
 	if err := row.Scan(
 		&result.ID,
@@ -65,9 +37,6 @@ func (r *accountDataBase) Info(ctx context.Context, passportNumber string) (*peo
 		&surnameNull,
 		&patronymicNull,
 		&addressNull,
-		&tasksArray,
-		&passportNumber,
-		&password,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, db.ErrNotExist
@@ -132,25 +101,152 @@ func (r *accountDataBase) Registration(ctx context.Context, newPeople people.Reg
 	return &id, nil
 }
 
-func (r *accountDataBase) Login(ctx context.Context, acc people.Registration) (int64, error) {
+// Login returns the id and the stored password hash of the person.
+func (r *accountDataBase) Login(ctx context.Context, passportNumber string) (int64, string, error) {
 	var id int64
-	row := r.db.QueryRowContext(ctx, "SELECT id FROM people WHERE passportNumber = $1 and password = $2", acc.PassportNumber, acc.Password)
+	var password string
+	row := r.db.QueryRowContext(ctx, "SELECT id, password FROM people WHERE passportNumber = $1", passportNumber)
 
-	if err := row.Scan(&id); err != nil {
+	if err := row.Scan(&id, &password); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return 0, db.ErrNotExist
+			return 0, "", db.ErrNotExist
+		}
+		return 0, "", err
+	}
+	return id, password, nil
+}
+
+func (r *accountDataBase) UpdatePassword(ctx context.Context, id int64, password string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE people SET password = $1 WHERE people.id = $2", password, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return db.ErrUpdateFailed
+	}
+
+	return nil
+}
+
+func (r *accountDataBase) GetAccess(ctx context.Context, id int64) (*people.Access, error) {
+	var result people.Access
+	var managerId sql.NullInt64
+	row := r.db.QueryRowContext(ctx, "SELECT id, role, managerId FROM people WHERE id = $1", id)
+
+	if err := row.Scan(&result.ID, &result.Role, &managerId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.ErrNotExist
+		}
+		return nil, err
+	}
+	if managerId.Valid {
+		result.ManagerID = &managerId.Int64
+	}
+	return &result, nil
+}
+
+func (r *accountDataBase) PutAccess(ctx context.Context, id int64, access people.Access) (*people.Access, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE people SET role = $1, managerId = $2 WHERE people.id = $3", access.Role, access.ManagerID, id)
+	if err != nil {
+		var pgxError *pgconn.PgError
+		if errors.As(err, &pgxError) {
+			// Нарушение внешнего ключа managerId
+			if pgxError.Code == "23503" {
+				return nil, db.ErrNotExist
+			}
+		}
+		return nil, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 0 {
+		return nil, db.ErrUpdateFailed
+	}
+
+	access.ID = id
+	return &access, nil
+}
+
+// LockTimer locks the row of the person until the transaction ends and
+// returns whether the person may run a single timer only. Every change that
+// starts a timer takes this lock first, so two requests of one person can
+// not both see no running timer and start one.
+func (r *accountDataBase) LockTimer(ctx context.Context, id int64) (bool, error) {
+	var singleTimer bool
+	row := r.db.QueryRowContext(ctx, "SELECT singleTimer FROM people WHERE id = $1 FOR UPDATE", id)
+	if err := row.Scan(&singleTimer); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, db.ErrNotExist
+		}
+		return false, err
+	}
+	return singleTimer, nil
+}
+
+func (r *accountDataBase) PutTimerPolicy(ctx context.Context, id int64, singleTimer bool) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE people SET singleTimer = $1 WHERE people.id = $2", singleTimer, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return db.ErrUpdateFailed
+	}
+
+	return nil
+}
+
+// GetReports returns the ids of the people reporting to the manager.
+func (r *accountDataBase) GetReports(ctx context.Context, managerId int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM people WHERE managerId = $1", managerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
-		return 0, err
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
-	return id, nil
+
+	return ids, nil
 }
 
-func (r *accountDataBase) Get(ctx context.Context, filter *people.Filter, pagination *people.Pagination) ([]people.Request, error) {
-	query := "SELECT id, name, surname, patronymic, address, tasks, passportNumber FROM people"
+// Get returns a page of people matching the filter. Non nil personIds
+// limits the result to these people.
+func (r *accountDataBase) Get(ctx context.Context, personIds []int64, filter *people.Filter, pagination *people.Pagination) ([]people.Request, error) {
+	query := "SELECT id, name, surname, patronymic, address, passportNumber FROM people"
 	var args []interface{}
+	var whereClauses []string
+
+	if personIds != nil {
+		args = append(args, pq.Array(personIds))
+		whereClauses = append(whereClauses, fmt.Sprintf("id = ANY($%d::INTEGER[])", len(args)))
+	}
 
 	if filter != nil {
-		var whereClauses []string
 		if filter.ID != nil {
 			args = append(args, *filter.ID)
 			whereClauses = append(whereClauses, fmt.Sprintf("id = $%d", len(args)))
@@ -173,12 +269,12 @@ func (r *accountDataBase) Get(ctx context.Context, filter *people.Filter, pagina
 		}
 		if filter.Tasks != nil {
 			args = append(args, pq.Array(*filter.Tasks))
-			whereClauses = append(whereClauses, fmt.Sprintf("tasks @> $%d", len(args)))
+			whereClauses = append(whereClauses, fmt.Sprintf("ARRAY(SELECT task.id FROM task WHERE task.personId = people.id) @> $%d::INTEGER[]", len(args)))
 		}
+	}
 
-		if len(whereClauses) > 0 {
-			query += " WHERE " + strings.Join(whereClauses, " AND ")
-		}
+	if len(whereClauses) > 0 {
+		query += " WHERE " + strings.Join(whereClauses, " AND ")
 	}
 
 	// Apply pagination
@@ -207,11 +303,10 @@ func (r *accountDataBase) Get(ctx context.Context, filter *people.Filter, pagina
 			surname        sql.NullString
 			patronymic     sql.NullString
 			address        sql.NullString
-			tasksArray     sql.NullString
 			passportNumber string
 		)
 
-		if err := rows.Scan(&id, &name, &surname, &patronymic, &address, &tasksArray, &passportNumber); err != nil {
+		if err := rows.Scan(&id, &name, &surname, &patronymic, &address, &passportNumber); err != nil {
 			return nil, err
 		}
 
@@ -235,82 +330,84 @@ func (r *accountDataBase) Get(ctx context.Context, filter *people.Filter, pagina
 			requestPeople.Address = address.String
 		}
 
-		if tasksArray.Valid {
-			taskIDs, err := parsePostgresArray(tasksArray.String)
-			if err != nil {
-				return nil, err
-			}
-			requestPeople.Tasks = make([]task.Task, 0, len(taskIDs))
-			for _, taskID := range taskIDs {
-				currTask, err := r.getTasks(ctx, taskID)
-				if err != nil {
-					// You might want to log this error or handle it differently
-					continue
-				}
-				requestPeople.Tasks = append(requestPeople.Tasks, currTask)
-			}
-		}
-
 		result = append(result, requestPeople)
 	}
-	return result, nil
-}
-
-func (r *accountDataBase) getTasks(ctx context.Context, taskID int64) (task.Task, error) {
-	row := r.db.QueryRowContext(ctx, "SELECT id, name, description, startTime, endTime, totalTime FROM task WHERE id = $1", taskID)
-
-	var (
-		currTask    task.Task
-		name        sql.NullString
-		description sql.NullString
-		startTime   sql.NullTime
-		endTime     sql.NullTime
-		totalTime   sql.NullInt64
-	)
-
-	err := row.Scan(&currTask.ID, &name, &description, &startTime, &endTime, &totalTime)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return task.Task{}, db.ErrTasks
-		}
-		return task.Task{}, err
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if name.Valid {
-		currTask.Name = name.String
-	}
-	if description.Valid {
-		currTask.Description = description.String
+	// Задачи всей страницы одним запросом
+	ids := make([]int64, 0, len(result))
+	for _, p := range result {
+		ids = append(ids, p.ID)
 	}
-	if startTime.Valid {
-		currTask.StartTime = startTime.Time
-	}
-	if endTime.Valid {
-		currTask.EndTime = &endTime.Time
+	tasks, err := r.getTasks(ctx, ids)
+	if err != nil {
+		return nil, err
 	}
-	if totalTime.Valid {
-		duration := time.Duration(totalTime.Int64)
-		currTask.TotalTime = &duration
+	for i := range result {
+		result[i].Tasks = tasks[result[i].ID]
+		if result[i].Tasks == nil {
+			result[i].Tasks = make([]task.Task, 0)
+		}
 	}
 
-	return currTask, nil
+	return result, nil
 }
 
-func parsePostgresArray(arrayStr string) ([]int64, error) {
-	// Remove the curly braces
-	arrayStr = strings.Trim(arrayStr, "{}")
+// getTasks loads the tasks of every given person and groups them by person id.
+func (r *accountDataBase) getTasks(ctx context.Context, personIds []int64) (map[int64][]task.Task, error) {
+	result := make(map[int64][]task.Task, len(personIds))
+	if len(personIds) == 0 {
+		return result, nil
+	}
 
-	// Split the string by comma
-	strNumbers := strings.Split(arrayStr, ",")
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, name, description, startTime, endTime, totalTime, personId, status FROM task WHERE personId = ANY($1) ORDER BY personId, id",
+		pq.Array(personIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	result := make([]int64, 0, len(strNumbers))
+	for rows.Next() {
+		var (
+			currTask    task.Task
+			name        sql.NullString
+			description sql.NullString
+			startTime   sql.NullTime
+			endTime     sql.NullTime
+			totalTime   sql.NullString
+		)
 
-	for _, strNum := range strNumbers {
-		num, err := strconv.ParseInt(strings.TrimSpace(strNum), 10, 64)
-		if err != nil {
+		if err := rows.Scan(&currTask.ID, &name, &description, &startTime, &endTime, &totalTime, &currTask.PersonID, &currTask.Status); err != nil {
 			return nil, err
 		}
-		result = append(result, num)
+
+		if name.Valid {
+			currTask.Name = name.String
+		}
+		if description.Valid {
+			currTask.Description = description.String
+		}
+		if startTime.Valid {
+			currTask.StartTime = startTime.Time
+		}
+		if endTime.Valid {
+			currTask.EndTime = &endTime.Time
+		}
+		if totalTime.Valid {
+			duration, err := db.ParseDuration(totalTime.String)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse duration: %w", err)
+			}
+			currTask.TotalTime = &duration
+		}
+
+		result[currTask.PersonID] = append(result[currTask.PersonID], currTask)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -366,17 +463,3 @@ func (r *accountDataBase) Delete(ctx context.Context, id int64) error {
 
 	return err
 }
-
-func (r *accountDataBase) AppendTask(ctx context.Context, id int64, task task.Task) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE people SET tasks = array_append(tasks, $1) WHERE people.id = $2", task.ID, id)
-	if err != nil {
-		var pgxError *pgconn.PgError
-		if errors.As(err, &pgxError) {
-			if pgxError.Code == "23505" {
-				return db.ErrDuplicate
-			}
-		}
-		return err
-	}
-	return nil
-}
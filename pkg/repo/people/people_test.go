@@ -0,0 +1,143 @@
+package people
+
+import (
+	"context"
+	"database/sql/driver"
+	"effectiveMobile/pkg/domain/people"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+const (
+	benchPeople       = 100
+	benchTasksPerUser = 50
+)
+
+// TestGetQueries reads a page of people with their tasks. Get must ask for
+// the tasks of the whole page at once, so the number of queries does not
+// grow with the number of people or tasks; sqlmock fails any query beyond
+// the two expected.
+func TestGetQueries(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	repo := NewPeopleDataBase(conn)
+
+	mock.ExpectQuery("SELECT id, name, surname, patronymic, address, passportNumber FROM people").
+		WillReturnRows(peopleRows())
+	mock.ExpectQuery("FROM task WHERE personId = ANY").
+		WillReturnRows(taskRows(0, benchPeople*benchTasksPerUser))
+
+	result, err := repo.Get(context.Background(), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != benchPeople {
+		t.Fatalf("got %d people, want %d", len(result), benchPeople)
+	}
+	for _, person := range result {
+		if len(person.Tasks) != benchTasksPerUser {
+			t.Fatalf("person %d has %d tasks, want %d", person.ID, len(person.Tasks), benchTasksPerUser)
+		}
+		for _, task := range person.Tasks {
+			if task.PersonID != person.ID {
+				t.Fatalf("task %d of person %d is given to person %d", task.ID, task.PersonID, person.ID)
+			}
+		}
+	}
+}
+
+// TestGetFilterTasks finds people by their tasks through task.personId.
+func TestGetFilterTasks(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	repo := NewPeopleDataBase(conn)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM people WHERE ARRAY(SELECT task.id FROM task WHERE task.personId = people.id) @> $1::INTEGER[]")).
+		WithArgs(pq.Array([]int64{1, 2})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "address", "passportNumber"}).
+			AddRow(int64(1), "Ivan", "Ivanov", "Ivanovich", "Moscow", "1234 567890"))
+	mock.ExpectQuery("FROM task WHERE personId = ANY").
+		WithArgs(pq.Array([]int64{1})).
+		WillReturnRows(taskRows(0, 2))
+
+	tasks := []int64{1, 2}
+	result, err := repo.Get(context.Background(), nil, &people.Filter{Tasks: &tasks}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || len(result[0].Tasks) != 2 {
+		t.Fatalf("got %+v, want person 1 with tasks 1 and 2", result)
+	}
+}
+
+// BenchmarkGet reads a page of people with their tasks through Get.
+// sqlmock answers at once, so this measures the scanning and grouping of
+// the rows, not the round trips, which TestGetQueries keeps at two.
+func BenchmarkGet(b *testing.B) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+	repo := NewPeopleDataBase(conn)
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		mock.ExpectQuery("SELECT id, name, surname, patronymic, address, passportNumber FROM people").
+			WillReturnRows(peopleRows())
+		mock.ExpectQuery("FROM task WHERE personId = ANY").
+			WillReturnRows(taskRows(0, benchPeople*benchTasksPerUser))
+		b.StartTimer()
+
+		result, err := repo.Get(context.Background(), nil, nil, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(result) != benchPeople || len(result[0].Tasks) != benchTasksPerUser {
+			b.Fatalf("got %d people with %d tasks", len(result), len(result[0].Tasks))
+		}
+	}
+	b.StopTimer()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func peopleRows() *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name", "surname", "patronymic", "address", "passportNumber"})
+	for p := 1; p <= benchPeople; p++ {
+		rows.AddRow(int64(p), "Ivan", "Ivanov", "Ivanovich", "Moscow", "1234 567890")
+	}
+	return rows
+}
+
+// taskRows returns count tasks starting from the task number from, the
+// tasks of a person follow each other as ORDER BY personId, id gives them.
+func taskRows(from int, count int) *sqlmock.Rows {
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "startTime", "endTime", "totalTime", "personId", "status"})
+	for t := from; t < from+count; t++ {
+		values := []driver.Value{
+			int64(t + 1), "task", "description", start, start.Add(90 * time.Minute), "01:30:00",
+			int64(t/benchTasksPerUser + 1), "finished",
+		}
+		rows.AddRow(values...)
+	}
+	return rows
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/pkg/logger"
+	"effectiveMobile/pkg/repo/people"
+	peopleI "effectiveMobile/pkg/repo/people/interface"
+	"effectiveMobile/pkg/repo/session"
+	sessionI "effectiveMobile/pkg/repo/session/interface"
+	"effectiveMobile/pkg/repo/task"
+	taskI "effectiveMobile/pkg/repo/task/interface"
+	"errors"
+)
+
+// Repositories bound to one transaction.
+type Repositories struct {
+	People  peopleI.PeopleRepository
+	Task    taskI.TaskRepository
+	Session sessionI.SessionRepository
+}
+
+// UnitOfWork runs several repository calls in one transaction: they commit
+// together when fn returns nil and roll back together otherwise.
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+type unitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) UnitOfWork {
+	return &unitOfWork{
+		db: db,
+	}
+}
+
+func (u *unitOfWork) WithTx(ctx context.Context, fn func(repos Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(Repositories{
+		People:  people.NewPeopleDataBase(tx),
+		Task:    task.NewTaskDataBase(tx),
+		Session: session.NewSessionDataBase(tx),
+	})
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			logger.FromContext(ctx).WithError(rbErr).Error("rollback")
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
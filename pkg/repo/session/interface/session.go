@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"effectiveMobile/pkg/domain/session"
+)
+
+type SessionRepository interface {
+	Post(ctx context.Context, newSession session.Session, refreshHash string) (*session.Session, error)
+	Get(ctx context.Context, id string) (*session.Session, error)
+	GetByRefresh(ctx context.Context, refreshHash string) (*session.Session, error)
+	GetActive(ctx context.Context, personId int64) ([]session.Session, error)
+	Rotate(ctx context.Context, id string) error
+	Revoke(ctx context.Context, id string) error
+	RevokeFamily(ctx context.Context, familyId string) error
+	RevokePerson(ctx context.Context, personId int64) error
+}
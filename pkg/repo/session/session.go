@@ -0,0 +1,160 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/session"
+	interfaces "effectiveMobile/pkg/repo/session/interface"
+	"errors"
+
+	"github.com/jackc/pgconn"
+)
+
+type sessionDataBase struct {
+	db db.DBTX
+}
+
+func NewSessionDataBase(conn db.DBTX) interfaces.SessionRepository {
+	return &sessionDataBase{
+		db: db.Instrument(conn, "session"),
+	}
+}
+
+func (r *sessionDataBase) Post(ctx context.Context, newSession session.Session, refreshHash string) (*session.Session, error) {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO session(id, personId, familyId, refreshHash, userAgent, createdAt, expiresAt) values($1, $2, $3, $4, $5, $6, $7)",
+		newSession.ID, newSession.PersonID, newSession.FamilyID, refreshHash, newSession.UserAgent, newSession.CreatedAt, newSession.ExpiresAt)
+	if err != nil {
+		var pgxError *pgconn.PgError
+		if errors.As(err, &pgxError) {
+			if pgxError.Code == "23505" {
+				return nil, db.ErrDuplicate
+			}
+		}
+		return nil, err
+	}
+
+	return &newSession, nil
+}
+
+func (r *sessionDataBase) Get(ctx context.Context, id string) (*session.Session, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT id, personId, familyId, userAgent, createdAt, expiresAt, rotatedAt, revokedAt FROM session WHERE id = $1", id)
+	return scanSession(row)
+}
+
+func (r *sessionDataBase) GetByRefresh(ctx context.Context, refreshHash string) (*session.Session, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT id, personId, familyId, userAgent, createdAt, expiresAt, rotatedAt, revokedAt FROM session WHERE refreshHash = $1", refreshHash)
+	return scanSession(row)
+}
+
+// GetActive returns the sessions of the person that can still be refreshed.
+func (r *sessionDataBase) GetActive(ctx context.Context, personId int64) ([]session.Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, personId, familyId, userAgent, createdAt, expiresAt, rotatedAt, revokedAt
+        FROM session
+        WHERE personId = $1 AND rotatedAt IS NULL AND revokedAt IS NULL AND expiresAt > (now() AT TIME ZONE 'UTC')
+        ORDER BY createdAt DESC
+    `, personId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]session.Session, 0)
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Rotate marks the session as exchanged for a new one. It fails when the
+// session was already rotated or revoked, so a refresh token is used once.
+func (r *sessionDataBase) Rotate(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE session SET rotatedAt = (now() AT TIME ZONE 'UTC') WHERE id = $1 AND rotatedAt IS NULL AND revokedAt IS NULL", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return db.ErrUpdateFailed
+	}
+
+	return nil
+}
+
+func (r *sessionDataBase) Revoke(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE session SET revokedAt = (now() AT TIME ZONE 'UTC') WHERE id = $1 AND revokedAt IS NULL", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return db.ErrNotExist
+	}
+
+	return nil
+}
+
+func (r *sessionDataBase) RevokeFamily(ctx context.Context, familyId string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE session SET revokedAt = (now() AT TIME ZONE 'UTC') WHERE familyId = $1 AND revokedAt IS NULL", familyId)
+	return err
+}
+
+// RevokePerson revokes every session of the person, so that tokens issued
+// with their former role stop working.
+func (r *sessionDataBase) RevokePerson(ctx context.Context, personId int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE session SET revokedAt = (now() AT TIME ZONE 'UTC') WHERE personId = $1 AND revokedAt IS NULL", personId)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row scanner) (*session.Session, error) {
+	var (
+		result    session.Session
+		userAgent sql.NullString
+		rotatedAt sql.NullTime
+		revokedAt sql.NullTime
+	)
+
+	err := row.Scan(&result.ID, &result.PersonID, &result.FamilyID, &userAgent, &result.CreatedAt, &result.ExpiresAt, &rotatedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.ErrNotExist
+		}
+		return nil, err
+	}
+
+	if userAgent.Valid {
+		result.UserAgent = userAgent.String
+	}
+	if rotatedAt.Valid {
+		result.RotatedAt = &rotatedAt.Time
+	}
+	if revokedAt.Valid {
+		result.RevokedAt = &revokedAt.Time
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"context"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/db/dbtest"
+	"effectiveMobile/pkg/domain/session"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRotateAndGetActive rotates a session once, refuses to rotate it or a
+// revoked one again and lists only the sessions that can still be
+// refreshed: neither rotated, nor revoked, nor expired.
+func TestRotateAndGetActive(t *testing.T) {
+	conn := dbtest.Migrated(t)
+	ctx := context.Background()
+	repo := NewSessionDataBase(conn)
+
+	var personId int64
+	err := conn.QueryRowContext(ctx,
+		"INSERT INTO people(passportNumber, password) VALUES ('1234 567890', 'x') RETURNING id",
+	).Scan(&personId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	for _, s := range []session.Session{
+		{ID: "active", ExpiresAt: now.Add(time.Hour)},
+		{ID: "rotated", ExpiresAt: now.Add(time.Hour)},
+		{ID: "revoked", ExpiresAt: now.Add(time.Hour)},
+		{ID: "expired", ExpiresAt: now.Add(-time.Minute)},
+	} {
+		s.PersonID, s.FamilyID, s.CreatedAt = personId, "family", now.Add(-time.Hour)
+		if _, err = repo.Post(ctx, s, "hash of "+s.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = repo.Rotate(ctx, "rotated"); err != nil {
+		t.Fatalf("first rotate: %v", err)
+	}
+	if err = repo.Rotate(ctx, "rotated"); !errors.Is(err, db.ErrUpdateFailed) {
+		t.Errorf("second rotate: got %v, want db.ErrUpdateFailed", err)
+	}
+	if err = repo.Revoke(ctx, "revoked"); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Rotate(ctx, "revoked"); !errors.Is(err, db.ErrUpdateFailed) {
+		t.Errorf("rotate of a revoked session: got %v, want db.ErrUpdateFailed", err)
+	}
+
+	active, err := repo.GetActive(ctx, personId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0].ID != "active" {
+		t.Errorf("got %d active sessions %v, want only \"active\"", len(active), active)
+	}
+}
@@ -7,11 +7,18 @@ import (
 )
 
 type TaskRepository interface {
-	Migrate(ctx context.Context) error
 	Post(ctx context.Context, newTask task.Task) (*task.Task, error)
 	Put(ctx context.Context, id int64, updateTask task.Task) (*task.Task, error)
 	Get(ctx context.Context, id int64) (*task.Task, error)
-	GetLaborCost(ctx context.Context, startTime time.Time, endTime time.Time) (task.Slice, error)
-	GetAll(ctx context.Context) ([]task.Task, error)
+	GetForUpdate(ctx context.Context, id int64) (*task.Task, error)
+	GetLaborCost(ctx context.Context, personId int64, startTime time.Time, endTime time.Time, statuses []task.Status) (task.Slice, error)
+	GetAll(ctx context.Context, personIds []int64, statuses []task.Status) ([]task.Task, error)
 	Delete(ctx context.Context, id int64) error
+	DeleteByPerson(ctx context.Context, personId int64) error
+	CountRunning(ctx context.Context) (int64, error)
+	GetRunning(ctx context.Context, personId int64) ([]task.Task, error)
+	StartInterval(ctx context.Context, taskId int64, startTime time.Time) error
+	StopInterval(ctx context.Context, taskId int64, endTime time.Time) error
+	PutIntervals(ctx context.Context, taskId int64, intervals []task.Interval) error
+	Overlapping(ctx context.Context, personId int64, exceptTaskId int64, startTime time.Time, endTime time.Time) (int64, error)
 }
@@ -0,0 +1,108 @@
+package task
+
+import (
+	"context"
+	"effectiveMobile/pkg/db/dbtest"
+	"effectiveMobile/pkg/domain/task"
+	"testing"
+	"time"
+)
+
+// TestGetLaborCostClipsIntervals counts only the part of every interval
+// inside the window: one straddles the start, one the end, one the whole
+// window and one lies outside. An open one still running today counts up to
+// the end of the window.
+func TestGetLaborCostClipsIntervals(t *testing.T) {
+	conn := dbtest.Migrated(t)
+	ctx := context.Background()
+	repo := NewTaskDataBase(conn)
+
+	var personId int64
+	err := conn.QueryRowContext(ctx,
+		"INSERT INTO people(passportNumber, password) VALUES ('1234 567890', 'x') RETURNING id",
+	).Scan(&personId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	at := func(d time.Duration) *time.Time {
+		v := from.Add(d)
+		return &v
+	}
+	post := func(name string, intervals ...task.Interval) int64 {
+		t.Helper()
+		created, err := repo.Post(ctx, task.Task{Name: name, StartTime: intervals[0].StartTime, PersonID: personId, Status: task.StatusPaused})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = repo.PutIntervals(ctx, created.ID, intervals); err != nil {
+			t.Fatal(err)
+		}
+		return created.ID
+	}
+
+	edges := post("edges",
+		task.Interval{StartTime: *at(-2 * time.Hour), EndTime: at(time.Hour)},
+		task.Interval{StartTime: *at(23 * time.Hour), EndTime: at(26 * time.Hour)},
+	)
+	whole := post("whole", task.Interval{StartTime: *at(-time.Hour), EndTime: at(25 * time.Hour)})
+	post("outside", task.Interval{StartTime: *at(-3 * time.Hour), EndTime: at(-time.Hour)})
+	open := post("open", task.Interval{StartTime: *at(time.Hour)})
+
+	tasks, err := repo.GetLaborCost(ctx, personId, from, to, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[int64]time.Duration)
+	for _, tk := range tasks {
+		got[tk.ID] = *tk.TotalTime
+	}
+	want := map[int64]time.Duration{edges: 2 * time.Hour, whole: 24 * time.Hour, open: 23 * time.Hour}
+	if len(got) != len(want) {
+		t.Fatalf("got tasks %v, want %v", got, want)
+	}
+	for id, d := range want {
+		if got[id] != d {
+			t.Errorf("task %d worked %v inside the window, want %v", id, got[id], d)
+		}
+	}
+}
+
+// TestGetLaborCostRunning counts a running task up to now when the window
+// reaches into the future.
+func TestGetLaborCostRunning(t *testing.T) {
+	conn := dbtest.Migrated(t)
+	ctx := context.Background()
+	repo := NewTaskDataBase(conn)
+
+	var personId int64
+	err := conn.QueryRowContext(ctx,
+		"INSERT INTO people(passportNumber, password) VALUES ('1234 567890', 'x') RETURNING id",
+	).Scan(&personId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	created, err := repo.Post(ctx, task.Task{Name: "running", StartTime: now.Add(-time.Hour), PersonID: personId, Status: task.StatusRunning})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.PutIntervals(ctx, created.ID, []task.Interval{{StartTime: now.Add(-time.Hour)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := repo.GetLaborCost(ctx, personId, now.Add(-2*time.Hour), now.Add(time.Hour), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("got %d tasks, want the running one", len(tasks))
+	}
+	// Часы базы и теста расходятся на время запроса
+	if worked := *tasks[0].TotalTime; worked < time.Hour-time.Minute || worked > time.Hour+time.Minute {
+		t.Errorf("running task worked %v, want about an hour", worked)
+	}
+}
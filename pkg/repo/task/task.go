@@ -9,48 +9,24 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
-	"log"
-	"strconv"
-	"strings"
+	"github.com/lib/pq"
 	"time"
 )
 
 type taskDataBase struct {
-	db *sql.DB
+	db db.DBTX
 }
 
-func NewTaskDataBase(db *sql.DB) interfaces.TaskRepository {
+func NewTaskDataBase(conn db.DBTX) interfaces.TaskRepository {
 	return &taskDataBase{
-		db: db,
+		db: db.Instrument(conn, "task"),
 	}
 }
 
-func (r *taskDataBase) Migrate(ctx context.Context) error {
-	accQuery := `
-    CREATE TABLE IF NOT EXISTS task (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		description TEXT,
- 		startTime TIMESTAMP NOT NULL,
-		endTime TIMESTAMP,
-		totalTime INTERVAL
-	);
-    `
-	_, err := r.db.ExecContext(ctx, accQuery)
-	if err != nil {
-		message := db.ErrMigrate.Error() + " book"
-		log.Printf("%q: %s\n", message, err.Error())
-		return db.ErrMigrate
-	}
-
-	return err
-}
-
 func (r *taskDataBase) Post(ctx context.Context, newTask task.Task) (*task.Task, error) {
 	var id int64
 
-	err := r.db.QueryRowContext(ctx, "INSERT INTO task(name, description, startTime) values($1, $2, $3) RETURNING id", newTask.Name, newTask.Description, newTask.StartTime).Scan(&id)
-	// Check if a task with the same books already exists
+	err := r.db.QueryRowContext(ctx, "INSERT INTO task(name, description, startTime, personId, status) values($1, $2, $3, $4, $5) RETURNING id", newTask.Name, newTask.Description, newTask.StartTime, newTask.PersonID, newTask.Status).Scan(&id)
 	if err != nil {
 		var pgxError *pgconn.PgError
 		if errors.As(err, &pgxError) {
@@ -67,14 +43,16 @@ func (r *taskDataBase) Post(ctx context.Context, newTask task.Task) (*task.Task,
 		Name:        newTask.Name,
 		Description: newTask.Description,
 		StartTime:   newTask.StartTime,
+		PersonID:    newTask.PersonID,
+		Status:      newTask.Status,
 	}
 
 	return requestTask, nil
 }
 
 func (r *taskDataBase) Put(ctx context.Context, id int64, updateTask task.Task) (*task.Task, error) {
-	res, err := r.db.ExecContext(ctx, "UPDATE task SET name = $1, description = $2, startTime = $3, endTime = $4, totalTime = $5 WHERE task.id = $6",
-		updateTask.Name, updateTask.Description, updateTask.StartTime, updateTask.EndTime, updateTask.TotalTime, id)
+	res, err := r.db.ExecContext(ctx, "UPDATE task SET name = $1, description = $2, startTime = $3, endTime = $4, totalTime = $5, status = $6 WHERE task.id = $7",
+		updateTask.Name, updateTask.Description, updateTask.StartTime, updateTask.EndTime, updateTask.TotalTime, updateTask.Status, id)
 	if err != nil {
 		var pgxError *pgconn.PgError
 		if errors.As(err, &pgxError) {
@@ -92,6 +70,8 @@ func (r *taskDataBase) Put(ctx context.Context, id int64, updateTask task.Task)
 		StartTime:   updateTask.StartTime,
 		EndTime:     updateTask.EndTime,
 		TotalTime:   updateTask.TotalTime,
+		PersonID:    updateTask.PersonID,
+		Status:      updateTask.Status,
 	}
 
 	rowsAffected, err := res.RowsAffected()
@@ -107,27 +87,71 @@ func (r *taskDataBase) Put(ctx context.Context, id int64, updateTask task.Task)
 }
 
 func (r *taskDataBase) Get(ctx context.Context, id int64) (*task.Task, error) {
-	row := r.db.QueryRowContext(ctx, "SELECT * FROM task WHERE id = $1", id)
+	return r.get(ctx, "SELECT id, name, description, startTime, endTime, totalTime, personId, status FROM task WHERE id = $1", id)
+}
+
+// GetForUpdate reads the task and locks its row until the transaction ends.
+func (r *taskDataBase) GetForUpdate(ctx context.Context, id int64) (*task.Task, error) {
+	return r.get(ctx, "SELECT id, name, description, startTime, endTime, totalTime, personId, status FROM task WHERE id = $1 FOR UPDATE", id)
+}
+
+func (r *taskDataBase) get(ctx context.Context, query string, id int64) (*task.Task, error) {
+	row := r.db.QueryRowContext(ctx, query, id)
 	var result task.Task
-	if err := row.Scan(&result.ID, &result.Name, &result.Description, &result.StartTime, &result.EndTime, &result.TotalTime); err != nil {
+	var endTime sql.NullTime
+	var totalTime sql.NullString
+	var personId sql.NullInt64
+	if err := row.Scan(&result.ID, &result.Name, &result.Description, &result.StartTime, &endTime, &totalTime, &personId, &result.Status); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, db.ErrNotExist
 		}
 		return nil, err
 	}
 
+	if endTime.Valid {
+		result.EndTime = &endTime.Time
+	}
+	if totalTime.Valid {
+		duration, err := db.ParseDuration(totalTime.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse duration: %w", err)
+		}
+		result.TotalTime = &duration
+	}
+	if personId.Valid {
+		result.PersonID = personId.Int64
+	}
+
+	intervals, err := r.getIntervals(ctx, []int64{result.ID})
+	if err != nil {
+		return nil, err
+	}
+	result.Intervals = intervals[result.ID]
+
 	return &result, nil
 }
 
-func (r *taskDataBase) GetLaborCost(ctx context.Context, startTime time.Time, endTime time.Time) (task.Slice, error) {
+// GetLaborCost returns the tasks of the person worked on inside the window.
+// TotalTime of every task is the part of its intervals that falls inside
+// the window, so an interval straddling an edge counts only up to the edge.
+// The interval of a running task counts as ending now. Nil statuses returns
+// tasks in every status.
+func (r *taskDataBase) GetLaborCost(ctx context.Context, personId int64, startTime time.Time, endTime time.Time, statuses []task.Status) (task.Slice, error) {
+	// EXTRACT(EPOCH) вместо INTERVAL: разность дат дает "1 day 02:00:00".
+	// Открытый интервал идет на часах до сих пор, считаем его до now()
 	query := `
-        SELECT id, name, description, startTime, endTime, totalTime
-        FROM task
-        WHERE startTime >= $1 AND startTime <= $2
-        AND (endTime IS NOT NULL AND endTime <= $2)
+        SELECT task.id, task.name, task.description, task.startTime, task.endTime, task.personId, task.status,
+            EXTRACT(EPOCH FROM SUM(LEAST(COALESCE(task_interval.endTime, now() AT TIME ZONE 'UTC'), $2) - GREATEST(task_interval.startTime, $1)))
+        FROM people
+        JOIN task ON task.personId = people.id
+        JOIN task_interval ON task_interval.taskId = task.id
+        WHERE people.id = $3
+        AND task_interval.startTime < $2 AND COALESCE(task_interval.endTime, now() AT TIME ZONE 'UTC') > $1
+        AND ($4::TEXT[] IS NULL OR task.status = ANY($4))
+        GROUP BY task.id
     `
 
-	rows, err := r.db.QueryContext(ctx, query, startTime, endTime)
+	rows, err := r.db.QueryContext(ctx, query, startTime, endTime, personId, statusArray(statuses))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tasks: %w", err)
 	}
@@ -137,9 +161,10 @@ func (r *taskDataBase) GetLaborCost(ctx context.Context, startTime time.Time, en
 	for rows.Next() {
 		var t task.Task
 		var endTime sql.NullTime
-		var totalTimeStr sql.NullString
+		var personId sql.NullInt64
+		var seconds float64
 
-		err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.StartTime, &endTime, &totalTimeStr)
+		err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.StartTime, &endTime, &personId, &t.Status, &seconds)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task row: %w", err)
 		}
@@ -147,14 +172,11 @@ func (r *taskDataBase) GetLaborCost(ctx context.Context, startTime time.Time, en
 		if endTime.Valid {
 			t.EndTime = &endTime.Time
 		}
-
-		if totalTimeStr.Valid {
-			duration, err := parseDuration(totalTimeStr.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse duration: %w", err)
-			}
-			t.TotalTime = &duration
+		if personId.Valid {
+			t.PersonID = personId.Int64
 		}
+		worked := time.Duration(seconds * float64(time.Second)).Round(time.Microsecond)
+		t.TotalTime = &worked
 
 		tasks = append(tasks, t)
 	}
@@ -166,35 +188,20 @@ func (r *taskDataBase) GetLaborCost(ctx context.Context, startTime time.Time, en
 	return tasks, nil
 }
 
-func parseDuration(s string) (time.Duration, error) {
-	parts := strings.Split(s, ":")
-	if len(parts) != 3 {
-		return 0, fmt.Errorf("invalid duration format")
-	}
-
-	hours, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, fmt.Errorf("invalid hours: %w", err)
-	}
-
-	minutes, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, fmt.Errorf("invalid minutes: %w", err)
-	}
-
-	seconds, err := strconv.ParseFloat(parts[2], 64)
-	if err != nil {
-		return 0, fmt.Errorf("invalid seconds: %w", err)
-	}
-
-	duration := time.Duration(hours)*time.Hour +
-		time.Duration(minutes)*time.Minute +
-		time.Duration(seconds*float64(time.Second))
-
-	return duration, nil
+// GetAll returns the tasks of the people. Nil personIds returns the tasks
+// of every person, nil statuses tasks in every status.
+func (r *taskDataBase) GetAll(ctx context.Context, personIds []int64, statuses []task.Status) ([]task.Task, error) {
+	return r.list(ctx, `
+        SELECT id, name, description, startTime, endTime, totalTime, personId, status FROM task
+        WHERE ($1::INTEGER[] IS NULL OR personId = ANY($1))
+        AND ($2::TEXT[] IS NULL OR status = ANY($2))
+    `, pq.Array(personIds), statusArray(statuses))
 }
-func (r *taskDataBase) GetAll(ctx context.Context) ([]task.Task, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT * FROM task")
+
+// list reads the tasks of the query and their intervals, two queries
+// whatever the number of tasks.
+func (r *taskDataBase) list(ctx context.Context, query string, args ...interface{}) ([]task.Task, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -205,8 +212,9 @@ func (r *taskDataBase) GetAll(ctx context.Context) ([]task.Task, error) {
 		var t task.Task
 		var endTime sql.NullTime
 		var totalTime sql.NullString
+		var personId sql.NullInt64
 
-		if err = rows.Scan(&t.ID, &t.Name, &t.Description, &t.StartTime, &endTime, &totalTime); err != nil {
+		if err = rows.Scan(&t.ID, &t.Name, &t.Description, &t.StartTime, &endTime, &totalTime, &personId, &t.Status); err != nil {
 			return nil, err
 		}
 
@@ -215,21 +223,73 @@ func (r *taskDataBase) GetAll(ctx context.Context) ([]task.Task, error) {
 		}
 
 		if totalTime.Valid {
-			duration, err := parseDuration(totalTime.String)
+			duration, err := db.ParseDuration(totalTime.String)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse duration: %w", err)
 			}
 			t.TotalTime = &duration
 		}
+		if personId.Valid {
+			t.PersonID = personId.Int64
+		}
 
 		tasks = append(tasks, t)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
+	// Строки задач отпускаем до запроса интервалов
+	rows.Close()
+
+	ids := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	intervals, err := r.getIntervals(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i].Intervals = intervals[tasks[i].ID]
+	}
 
 	return tasks, nil
 }
+
+// getIntervals loads the intervals of every given task in start order and
+// groups them by task id.
+func (r *taskDataBase) getIntervals(ctx context.Context, taskIds []int64) (map[int64][]task.Interval, error) {
+	result := make(map[int64][]task.Interval, len(taskIds))
+	if len(taskIds) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT taskId, startTime, endTime FROM task_interval WHERE taskId = ANY($1) ORDER BY taskId, startTime",
+		pq.Array(taskIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId int64
+		var interval task.Interval
+		var endTime sql.NullTime
+		if err := rows.Scan(&taskId, &interval.StartTime, &endTime); err != nil {
+			return nil, err
+		}
+		if endTime.Valid {
+			interval.EndTime = &endTime.Time
+		}
+		result[taskId] = append(result[taskId], interval)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
 func (r *taskDataBase) Delete(ctx context.Context, id int64) error {
 	res, err := r.db.ExecContext(ctx, "DELETE FROM task WHERE id = $1", id)
 	if err != nil {
@@ -247,3 +307,109 @@ func (r *taskDataBase) Delete(ctx context.Context, id int64) error {
 
 	return err
 }
+
+func (r *taskDataBase) DeleteByPerson(ctx context.Context, personId int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM task WHERE personId = $1", personId)
+	return err
+}
+
+// CountRunning returns the number of running tasks, paused tasks are not
+// counted.
+func (r *taskDataBase) CountRunning(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM task WHERE status = $1", task.StatusRunning).Scan(&count)
+	return count, err
+}
+
+// GetRunning returns the running tasks of the person and locks their rows
+// until the transaction ends.
+func (r *taskDataBase) GetRunning(ctx context.Context, personId int64) ([]task.Task, error) {
+	return r.list(ctx, `
+        SELECT id, name, description, startTime, endTime, totalTime, personId, status FROM task
+        WHERE personId = $1 AND status = $2
+        ORDER BY id
+        FOR UPDATE
+    `, personId, task.StatusRunning)
+}
+
+// StartInterval opens a running interval of the task. It returns
+// db.ErrDuplicate when the task already has one.
+func (r *taskDataBase) StartInterval(ctx context.Context, taskId int64, startTime time.Time) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO task_interval(taskId, startTime) VALUES ($1, $2)", taskId, startTime)
+	if err != nil {
+		var pgxError *pgconn.PgError
+		if errors.As(err, &pgxError) {
+			if pgxError.Code == "23505" {
+				return db.ErrDuplicate
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// StopInterval closes the running interval of the task. It returns
+// db.ErrNotExist when the task has none.
+func (r *taskDataBase) StopInterval(ctx context.Context, taskId int64, endTime time.Time) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE task_interval SET endTime = $1 WHERE taskId = $2 AND endTime IS NULL", endTime, taskId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return db.ErrNotExist
+	}
+
+	return nil
+}
+
+// PutIntervals replaces the intervals of the task.
+func (r *taskDataBase) PutIntervals(ctx context.Context, taskId int64, intervals []task.Interval) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM task_interval WHERE taskId = $1", taskId); err != nil {
+		return err
+	}
+	for _, interval := range intervals {
+		_, err := r.db.ExecContext(ctx, "INSERT INTO task_interval(taskId, startTime, endTime) VALUES ($1, $2, $3)",
+			taskId, interval.StartTime, interval.EndTime)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Overlapping returns the id of a task of the person, other than
+// exceptTaskId, that was worked on between startTime and endTime, or 0.
+// A running interval lasts until now.
+func (r *taskDataBase) Overlapping(ctx context.Context, personId int64, exceptTaskId int64, startTime time.Time, endTime time.Time) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+        SELECT task.id FROM task
+        JOIN task_interval ON task_interval.taskId = task.id
+        WHERE task.personId = $1 AND task.id <> $2
+        AND task_interval.startTime < $4
+        AND COALESCE(task_interval.endTime, now() AT TIME ZONE 'UTC') > $3
+        LIMIT 1
+    `, personId, exceptTaskId, startTime, endTime).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// statusArray passes statuses as a TEXT[] parameter, nil becomes NULL.
+func statusArray(statuses []task.Status) interface{} {
+	if statuses == nil {
+		return pq.StringArray(nil)
+	}
+	result := make(pq.StringArray, 0, len(statuses))
+	for _, status := range statuses {
+		result = append(result, string(status))
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"effectiveMobile/pkg/domain/task"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestGetRunning locks the running tasks of the person and reads them with
+// their intervals in two queries whatever the number of tasks.
+func TestGetRunning(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM task\s+WHERE personId = \$1 AND status = \$2\s+ORDER BY id\s+FOR UPDATE`).
+		WithArgs(int64(7), task.StatusRunning).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "startTime", "endTime", "totalTime", "personId", "status"}).
+			AddRow(int64(1), "first", "", start, nil, "01:00:00", int64(7), "running").
+			AddRow(int64(2), "second", "", start, nil, nil, int64(7), "running"))
+	mock.ExpectQuery(`FROM task_interval WHERE taskId = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"taskId", "startTime", "endTime"}).
+			AddRow(int64(1), start, start.Add(time.Hour)).
+			AddRow(int64(1), start.Add(2*time.Hour), nil).
+			AddRow(int64(2), start.Add(3*time.Hour), nil))
+
+	tasks, err := NewTaskDataBase(conn).GetRunning(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2", len(tasks))
+	}
+	if len(tasks[0].Intervals) != 2 || len(tasks[1].Intervals) != 1 {
+		t.Fatalf("got %d and %d intervals, want 2 and 1", len(tasks[0].Intervals), len(tasks[1].Intervals))
+	}
+	if tasks[0].TotalTime == nil || *tasks[0].TotalTime != time.Hour {
+		t.Errorf("first task total time = %v, want 1h", tasks[0].TotalTime)
+	}
+	if tasks[0].Intervals[1].EndTime != nil {
+		t.Errorf("open interval has end time %v", tasks[0].Intervals[1].EndTime)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/people"
+	"effectiveMobile/pkg/domain/task"
+)
+
+// checkTaskAccess allows a person to work with their own tasks, a manager
+// with the tasks of their reports and an admin with every task.
+func (s *service) checkTaskAccess(ctx context.Context, userId int64, personId int64) error {
+	if userId == personId {
+		return nil
+	}
+	actor, err := s.rPeople.GetAccess(ctx, userId)
+	if err != nil {
+		return err
+	}
+	switch actor.Role {
+	case people.RoleAdmin:
+		return nil
+	case people.RoleManager:
+		target, err := s.rPeople.GetAccess(ctx, personId)
+		if err != nil {
+			return err
+		}
+		if target.ManagerID != nil && *target.ManagerID == userId {
+			return nil
+		}
+	}
+	return db.ErrForbidden
+}
+
+// checkPeopleAccess allows a person to manage their own row and an admin
+// to manage every row.
+func (s *service) checkPeopleAccess(ctx context.Context, userId int64, personId int64) error {
+	if userId == personId {
+		return nil
+	}
+	actor, err := s.rPeople.GetAccess(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if actor.Role != people.RoleAdmin {
+		return db.ErrForbidden
+	}
+	return nil
+}
+
+// checkReportAccess allows a person to see their own labor cost and
+// managers and admins to see the labor cost of anyone.
+func (s *service) checkReportAccess(ctx context.Context, userId int64, personId int64) error {
+	if userId == personId {
+		return nil
+	}
+	actor, err := s.rPeople.GetAccess(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if actor.Role != people.RoleAdmin && actor.Role != people.RoleManager {
+		return db.ErrForbidden
+	}
+	return nil
+}
+
+// accessibleTask loads the task and makes sure the person may work with it.
+// Inside withTx the task row stays locked until the transaction ends.
+func (s *service) accessibleTask(ctx context.Context, userId int64, taskId int64) (*task.Task, error) {
+	currTask, err := s.rTask.GetForUpdate(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkTaskAccess(ctx, userId, currTask.PersonID); err != nil {
+		return nil, err
+	}
+	return currTask, nil
+}
+
+// readScope returns the people whose tasks the caller may read: themselves,
+// plus their reports for a manager, or nil for an admin who reads every task.
+func (s *service) readScope(ctx context.Context, userId string) ([]int64, error) {
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := s.rPeople.GetAccess(ctx, userIdInt)
+	if err != nil {
+		return nil, err
+	}
+	switch actor.Role {
+	case people.RoleAdmin:
+		return nil, nil
+	case people.RoleManager:
+		reports, err := s.rPeople.GetReports(ctx, userIdInt)
+		if err != nil {
+			return nil, err
+		}
+		return append(reports, userIdInt), nil
+	default:
+		return []int64{userIdInt}, nil
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"effectiveMobile/pkg/db"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectAccess answers the role lookup of the person.
+func expectAccess(mock sqlmock.Sqlmock, id int64, role string, managerId interface{}) {
+	mock.ExpectQuery("SELECT id, role, managerId FROM people").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "managerId"}).AddRow(id, role, managerId))
+}
+
+// accessCase is a caller, person 1, working with the rows of a person.
+type accessCase struct {
+	name     string
+	personId int64
+	expect   func(mock sqlmock.Sqlmock)
+	want     error
+}
+
+var (
+	selfCase     = accessCase{name: "self", personId: 1, expect: func(sqlmock.Sqlmock) {}}
+	adminCase    = accessCase{name: "admin", personId: 2, expect: func(mock sqlmock.Sqlmock) { expectAccess(mock, 1, "admin", nil) }}
+	employeeCase = accessCase{name: "employee", personId: 2, expect: func(mock sqlmock.Sqlmock) { expectAccess(mock, 1, "employee", nil) }, want: db.ErrForbidden}
+)
+
+func runAccessCases(t *testing.T, check func(s *service, personId int64) error, cases ...accessCase) {
+	t.Helper()
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			tt.expect(mock)
+			if err := check(s, tt.personId); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTaskAccess(t *testing.T) {
+	runAccessCases(t, func(s *service, personId int64) error {
+		return s.checkTaskAccess(context.Background(), 1, personId)
+	},
+		selfCase, adminCase, employeeCase,
+		accessCase{name: "manager of the person", personId: 2, expect: func(mock sqlmock.Sqlmock) {
+			expectAccess(mock, 1, "manager", nil)
+			expectAccess(mock, 2, "employee", int64(1))
+		}},
+		accessCase{name: "another manager", personId: 2, expect: func(mock sqlmock.Sqlmock) {
+			expectAccess(mock, 1, "manager", nil)
+			expectAccess(mock, 2, "employee", int64(3))
+		}, want: db.ErrForbidden},
+		accessCase{name: "manager of nobody", personId: 2, expect: func(mock sqlmock.Sqlmock) {
+			expectAccess(mock, 1, "manager", nil)
+			expectAccess(mock, 2, "employee", nil)
+		}, want: db.ErrForbidden},
+	)
+}
+
+func TestCheckPeopleAccess(t *testing.T) {
+	runAccessCases(t, func(s *service, personId int64) error {
+		return s.checkPeopleAccess(context.Background(), 1, personId)
+	},
+		selfCase, adminCase, employeeCase,
+		accessCase{name: "manager", personId: 2, expect: func(mock sqlmock.Sqlmock) {
+			expectAccess(mock, 1, "manager", nil)
+		}, want: db.ErrForbidden},
+	)
+}
+
+func TestCheckReportAccess(t *testing.T) {
+	runAccessCases(t, func(s *service, personId int64) error {
+		return s.checkReportAccess(context.Background(), 1, personId)
+	},
+		selfCase, adminCase, employeeCase,
+		accessCase{name: "manager", personId: 2, expect: func(mock sqlmock.Sqlmock) {
+			expectAccess(mock, 1, "manager", nil)
+		}},
+	)
+}
+
+func TestReadScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+		want   []int64
+	}{
+		{"admin", func(mock sqlmock.Sqlmock) { expectAccess(mock, 1, "admin", nil) }, nil},
+		{"manager", func(mock sqlmock.Sqlmock) {
+			expectAccess(mock, 1, "manager", nil)
+			mock.ExpectQuery("SELECT id FROM people WHERE managerId = ").
+				WithArgs(int64(1)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(2)).AddRow(int64(3)))
+		}, []int64{2, 3, 1}},
+		{"employee", func(mock sqlmock.Sqlmock) { expectAccess(mock, 1, "employee", nil) }, []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			tt.expect(mock)
+			got, err := s.readScope(context.Background(), "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
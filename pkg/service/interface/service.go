@@ -3,25 +3,40 @@ package interfaces
 import (
 	"context"
 	"effectiveMobile/pkg/domain/people"
+	"effectiveMobile/pkg/domain/session"
 	"effectiveMobile/pkg/domain/task"
 )
 
 type ServiceUseCase interface {
-	Migrate(ctx context.Context) error
-
 	// People
-	InfoPeople(ctx context.Context, passportSerie string, passportNumber string) (*people.Info, error)
+	InfoPeople(ctx context.Context, userId string, passportSerie string, passportNumber string) (*people.Info, error)
 	Registration(ctx context.Context, newPeople people.Registration) (*int64, error)
 	Login(ctx context.Context, people people.Registration) (int64, error)
-	GetPeople(ctx context.Context, filter *people.Filter, pagination *people.Pagination) ([]people.Request, error)
-	PutPeople(ctx context.Context, id string, updatePeople people.Info) (*people.Info, error)
-	DeletePeople(ctx context.Context, id string) error
+	GetPeople(ctx context.Context, userId string, filter *people.Filter, pagination *people.Pagination) ([]people.Request, error)
+	PutPeople(ctx context.Context, userId string, id string, updatePeople people.Info) (*people.Info, error)
+	DeletePeople(ctx context.Context, userId string, id string) error
+	PutAccess(ctx context.Context, userId string, id string, access people.Access) (*people.Access, error)
+
+	// Session
+	CreateSession(ctx context.Context, personId int64, userAgent string) (*session.Session, string, error)
+	RefreshSession(ctx context.Context, refreshToken string, userAgent string) (*session.Session, string, error)
+	CheckSession(ctx context.Context, sessionId string) error
+	Logout(ctx context.Context, sessionId string) error
+	GetSessions(ctx context.Context, userId string) ([]session.Session, error)
+	RevokeSession(ctx context.Context, userId string, sessionId string) error
 
 	//Task
-	TaskStart(ctx context.Context, id string, newTask task.Task) (*task.Task, error)
-	TaskFinish(ctx context.Context, taskId string) (*task.Task, error)
-	TaskPut(ctx context.Context, id string, updateTask task.Task) (*task.Task, error)
-	GetTask(ctx context.Context, startTimeStr string, endTimeStr string) ([]task.Task, error)
-	GetAllTask(ctx context.Context) ([]task.Task, error)
-	DeleteTask(ctx context.Context, id string) error
+	TaskStart(ctx context.Context, id string, newTask task.Task, onRunning task.OnRunning) (*task.Task, error)
+	TaskFinish(ctx context.Context, userId string, taskId string) (*task.Task, error)
+	TaskPause(ctx context.Context, userId string, taskId string) (*task.Task, error)
+	TaskPlan(ctx context.Context, id string, newTask task.Task) (*task.Task, error)
+	TaskRun(ctx context.Context, userId string, taskId string, onRunning task.OnRunning) (*task.Task, error)
+	TaskCancel(ctx context.Context, userId string, taskId string) (*task.Task, error)
+	PutTimerPolicy(ctx context.Context, userId string, id string, policy people.TimerPolicy) (*people.TimerPolicy, error)
+	TaskRecord(ctx context.Context, id string, entry task.Entry) (*task.Task, error)
+	TaskPatch(ctx context.Context, userId string, taskId string, patch task.Patch) (*task.Task, error)
+	GetTask(ctx context.Context, userId string, personId string, startTimeStr string, endTimeStr string, statusStr string) (*task.Report, error)
+	GetAllTask(ctx context.Context, userId string, statusStr string) ([]task.Task, error)
+	GetPersonTasks(ctx context.Context, userId string, personId string, statusStr string) ([]task.Task, error)
+	DeleteTask(ctx context.Context, userId string, taskId string) error
 }
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"crypto/subtle"
+	"effectiveMobile/pkg/db"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (s *service) hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.bcryptCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", fmt.Errorf("%w: password must not exceed 72 bytes", db.ErrValidate)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// checkPassword compares the password with the stored value. Rows created
+// before hashing was introduced still hold the plaintext, so needRehash
+// reports that the stored value should be replaced by a fresh hash.
+func (s *service) checkPassword(stored string, password string) (ok bool, needRehash bool, err error) {
+	if !isBcryptHash(stored) {
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return ok, ok, nil
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(stored), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(stored))
+	if err != nil {
+		return false, false, err
+	}
+	return true, cost != s.bcryptCost, nil
+}
+
+// burnPassword spends as much time as checkPassword does on a stored hash,
+// so that an unknown passport is not answered faster than a wrong password.
+func (s *service) burnPassword(password string) {
+	_ = bcrypt.CompareHashAndPassword(s.dummyHash(), []byte(password))
+}
+
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/people"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func mustHash(t *testing.T, password string, cost int) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func TestCheckPassword(t *testing.T) {
+	s := newTestService(nil)
+	tests := []struct {
+		name       string
+		stored     string
+		password   string
+		ok         bool
+		needRehash bool
+	}{
+		{"plaintext", "secret", "secret", true, true},
+		{"plaintext mismatch", "secret", "other", false, false},
+		{"hash", mustHash(t, "secret", bcrypt.MinCost), "secret", true, false},
+		{"hash mismatch", mustHash(t, "secret", bcrypt.MinCost), "other", false, false},
+		{"hash of another cost", mustHash(t, "secret", bcrypt.MinCost+1), "secret", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needRehash, err := s.checkPassword(tt.stored, tt.password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok || needRehash != tt.needRehash {
+				t.Errorf("got ok %v and needRehash %v, want %v and %v", ok, needRehash, tt.ok, tt.needRehash)
+			}
+		})
+	}
+}
+
+func TestHashPasswordTooLong(t *testing.T) {
+	s := newTestService(nil)
+	if _, err := s.hashPassword(strings.Repeat("п", 40)); !errors.Is(err, db.ErrValidate) {
+		t.Errorf("got %v, want db.ErrValidate", err)
+	}
+}
+
+// bcryptOf matches an argument that is a bcrypt hash of the password made
+// with the cost of the test service.
+type bcryptOf string
+
+func (b bcryptOf) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+	if !ok || bcrypt.CompareHashAndPassword([]byte(hash), []byte(b)) != nil {
+		return false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost == bcrypt.MinCost
+}
+
+// TestLoginRehash logs in with a password stored before hashing and with a
+// hash of an old cost. Both are replaced by a fresh hash, a current one is
+// left alone.
+func TestLoginRehash(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored string
+		rehash bool
+	}{
+		{"plaintext", "secret", true},
+		{"old cost", mustHash(t, "secret", bcrypt.MinCost+1), true},
+		{"current", mustHash(t, "secret", bcrypt.MinCost), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			mock.ExpectQuery("SELECT id, password FROM people WHERE passportNumber = ").
+				WithArgs("1234 567890").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(int64(1), tt.stored))
+			if tt.rehash {
+				mock.ExpectExec("UPDATE people SET password = ").
+					WithArgs(bcryptOf("secret"), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			id, err := s.Login(context.Background(), people.Registration{PassportNumber: "1234 567890", Password: "secret"})
+			if err != nil || id != 1 {
+				t.Errorf("got %d, %v, want 1", id, err)
+			}
+		})
+	}
+}
+
+func TestLoginRefused(t *testing.T) {
+	tests := []struct {
+		name string
+		rows *sqlmock.Rows
+	}{
+		{"unknown passport", sqlmock.NewRows([]string{"id", "password"})},
+		{"wrong password", sqlmock.NewRows([]string{"id", "password"}).AddRow(int64(1), mustHash(t, "secret", bcrypt.MinCost))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			mock.ExpectQuery("SELECT id, password FROM people WHERE passportNumber = ").WillReturnRows(tt.rows)
+
+			_, err := s.Login(context.Background(), people.Registration{PassportNumber: "1234 567890", Password: "other"})
+			if !errors.Is(err, db.ErrNotExist) {
+				t.Errorf("got %v, want db.ErrNotExist", err)
+			}
+		})
+	}
+}
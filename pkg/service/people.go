@@ -4,11 +4,23 @@ import (
 	"context"
 	"effectiveMobile/pkg/db"
 	"effectiveMobile/pkg/domain/people"
+	"effectiveMobile/pkg/logger"
+	"effectiveMobile/pkg/metrics"
+	"errors"
+	"fmt"
+	"slices"
 	"strconv"
 )
 
 func (s *service) Registration(ctx context.Context, newPeople people.Registration) (*int64, error) {
+	ctx, span := startSpan(ctx, "Registration")
+	defer span.End()
+
 	err := newPeople.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", db.ErrValidate, err)
+	}
+	newPeople.Password, err = s.hashPassword(newPeople.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -16,22 +28,62 @@ func (s *service) Registration(ctx context.Context, newPeople people.Registratio
 	if err != nil {
 		return nil, err
 	}
+	metrics.Registrations.Inc()
 	return result, nil
 }
 
 func (s *service) Login(ctx context.Context, people people.Registration) (int64, error) {
-	id, err := s.rPeople.Login(ctx, people)
+	ctx, span := startSpan(ctx, "Login")
+	defer span.End()
+
+	id, stored, err := s.rPeople.Login(ctx, people.PassportNumber)
+	if errors.Is(err, db.ErrNotExist) {
+		metrics.LoginFailures.Inc()
+		// Время ответа не должно выдавать, зарегистрирован ли паспорт
+		s.burnPassword(people.Password)
+	}
 	if err != nil {
 		return 0, err
 	}
+
+	ok, needRehash, err := s.checkPassword(stored, people.Password)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		metrics.LoginFailures.Inc()
+		// Не раскрываем, что именно не совпало
+		return 0, db.ErrNotExist
+	}
+
+	if needRehash {
+		hash, err := s.hashPassword(people.Password)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).WithField("personId", id).Error("rehash password")
+			return id, nil
+		}
+		if err = s.rPeople.UpdatePassword(ctx, id, hash); err != nil {
+			logger.FromContext(ctx).WithError(err).WithField("personId", id).Error("rehash password")
+		}
+	}
 	return id, nil
 }
 
-func (s *service) PutPeople(ctx context.Context, id string, updatePeople people.Info) (*people.Info, error) {
+func (s *service) PutPeople(ctx context.Context, userId string, id string, updatePeople people.Info) (*people.Info, error) {
+	ctx, span := startSpan(ctx, "PutPeople")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return nil, err
+	}
 	idInt, err := s.checkIdParam(id)
 	if err != nil {
 		return nil, err
 	}
+	if err = s.checkPeopleAccess(ctx, userIdInt, idInt); err != nil {
+		return nil, err
+	}
 
 	// Валидация обновляемых данных
 	result, err := s.rPeople.Put(ctx, idInt, updatePeople)
@@ -42,15 +94,49 @@ func (s *service) PutPeople(ctx context.Context, id string, updatePeople people.
 	return result, nil
 }
 
-func (s *service) GetPeople(ctx context.Context, filter *people.Filter, pagination *people.Pagination) ([]people.Request, error) {
-	result, err := s.rPeople.Get(ctx, filter, pagination)
+// GetPeople returns everybody to an admin, a manager and their reports to a
+// manager and only the caller to an employee. An empty userId comes from an
+// anonymous route and lists everybody without their tasks. Passport
+// numbers are shown in full to admins only.
+func (s *service) GetPeople(ctx context.Context, userId string, filter *people.Filter, pagination *people.Pagination) ([]people.Request, error) {
+	ctx, span := startSpan(ctx, "GetPeople")
+	defer span.End()
+
+	var personIds []int64
+	isAdmin := false
+	if userId != "" {
+		var err error
+		personIds, err = s.readScope(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		isAdmin = personIds == nil
+	}
+
+	result, err := s.rPeople.Get(ctx, personIds, filter, pagination)
 	if err != nil {
 		return nil, err
 	}
+
+	for i := range result {
+		if !isAdmin {
+			result[i].PassportNumber = people.MaskPassport(result[i].PassportNumber)
+		}
+		// Анониму задачи не показываются
+		if userId == "" {
+			result[i].Tasks = nil
+		}
+	}
 	return result, nil
 }
 
-func (s *service) InfoPeople(ctx context.Context, passportSerie string, passportNumber string) (*people.Info, error) {
+// InfoPeople finds a person by passport. An authorized caller only finds
+// the people they may read, the others are reported as not existing so that
+// the lookup does not tell whose passport it is.
+func (s *service) InfoPeople(ctx context.Context, userId string, passportSerie string, passportNumber string) (*people.Info, error) {
+	ctx, span := startSpan(ctx, "InfoPeople")
+	defer span.End()
+
 	if len(passportSerie) != 4 || len(passportNumber) != 6 {
 		return nil, db.ErrValidate
 	} else if _, err := strconv.Atoi(passportSerie); err != nil {
@@ -63,18 +149,146 @@ func (s *service) InfoPeople(ctx context.Context, passportSerie string, passport
 	if err != nil {
 		return nil, err
 	}
+
+	// userId пуст, если маршрут открыт для анонимов
+	if userId != "" {
+		personIds, err := s.readScope(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		if personIds != nil && !slices.Contains(personIds, result.ID) {
+			return nil, db.ErrNotExist
+		}
+	}
 	return result, nil
 }
 
-func (s *service) DeletePeople(ctx context.Context, id string) error {
+func (s *service) DeletePeople(ctx context.Context, userId string, id string) error {
+	ctx, span := startSpan(ctx, "DeletePeople")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return err
+	}
 	idInt, err := s.checkIdParam(id)
 	if err != nil {
 		return err
 	}
+	if err = s.checkPeopleAccess(ctx, userIdInt, idInt); err != nil {
+		return err
+	}
 
-	err = s.rPeople.Delete(ctx, idInt)
+	// Задачи удаляются вместе с человеком или не удаляются вовсе
+	return s.withTx(ctx, func(tx *service) error {
+		if err := tx.rTask.DeleteByPerson(ctx, idInt); err != nil {
+			return err
+		}
+		return tx.rPeople.Delete(ctx, idInt)
+	})
+}
+
+// PutAccess changes the role and the manager of a person. Only admins may
+// do it, and not for themselves so that the last admin can not lock out.
+// Tokens carry the role, so a new role revokes the sessions of the person
+// and they log in again with it. A manager may not report, directly or
+// through other managers, to the person.
+func (s *service) PutAccess(ctx context.Context, userId string, id string, access people.Access) (*people.Access, error) {
+	ctx, span := startSpan(ctx, "PutAccess")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	idInt, err := s.checkIdParam(id)
+	if err != nil {
+		return nil, err
+	}
+	if userIdInt == idInt {
+		return nil, db.ErrForbidden
+	}
+	actor, err := s.rPeople.GetAccess(ctx, userIdInt)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Role != people.RoleAdmin {
+		return nil, db.ErrForbidden
+	}
+	if err = access.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", db.ErrValidate, err)
+	}
+
+	var result *people.Access
+	err = s.withTx(ctx, func(tx *service) error {
+		curr, err := tx.rPeople.GetAccess(ctx, idInt)
+		if err != nil {
+			return err
+		}
+		if err = tx.checkManagerChain(ctx, idInt, access.ManagerID); err != nil {
+			return err
+		}
+		result, err = tx.rPeople.PutAccess(ctx, idInt, access)
+		if err != nil {
+			return err
+		}
+		if curr.Role != access.Role {
+			return tx.rSession.RevokePerson(ctx, idInt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkManagerChain refuses a manager who is the person or reports to them
+// through the chain of managers, which would make the chain a cycle.
+func (s *service) checkManagerChain(ctx context.Context, personId int64, managerId *int64) error {
+	seen := make(map[int64]bool)
+	for managerId != nil && !seen[*managerId] {
+		if *managerId == personId {
+			return fmt.Errorf("%w: managerId reports to the person", db.ErrValidate)
+		}
+		seen[*managerId] = true
+		manager, err := s.rPeople.GetAccess(ctx, *managerId)
+		if err != nil {
+			return err
+		}
+		managerId = manager.ManagerID
 	}
 	return nil
 }
+
+// PutTimerPolicy lets an admin allow or forbid a person to run several task
+// timers at once.
+func (s *service) PutTimerPolicy(ctx context.Context, userId string, id string, policy people.TimerPolicy) (*people.TimerPolicy, error) {
+	ctx, span := startSpan(ctx, "PutTimerPolicy")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return nil, err
+	}
+	idInt, err := s.checkIdParam(id)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := s.rPeople.GetAccess(ctx, userIdInt)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Role != people.RoleAdmin {
+		return nil, db.ErrForbidden
+	}
+	if err = policy.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", db.ErrValidate, err)
+	}
+
+	if err = s.rPeople.PutTimerPolicy(ctx, idInt, *policy.SingleTimer); err != nil {
+		return nil, err
+	}
+	policy.ID = idInt
+	return &policy, nil
+}
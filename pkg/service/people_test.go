@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/people"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestPutAccess changes the access of person 2 as admin 1. A new role
+// revokes the sessions of the person, whose tokens carry the old one, and a
+// manager reporting to the person is refused.
+func TestPutAccess(t *testing.T) {
+	managerId := func(id int64) *int64 { return &id }
+	tests := []struct {
+		name   string
+		access people.Access
+		expect func(mock sqlmock.Sqlmock)
+		want   error
+	}{
+		{
+			name:   "demoted",
+			access: people.Access{Role: people.RoleEmployee, ManagerID: managerId(3)},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectAccess(mock, 3, "manager", nil)
+				expectPutAccess(mock)
+				mock.ExpectExec("UPDATE session SET revokedAt = .* WHERE personId = ").
+					WithArgs(int64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "same role",
+			access: people.Access{Role: people.RoleManager},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPutAccess(mock)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "own manager",
+			access: people.Access{Role: people.RoleManager, ManagerID: managerId(2)},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectRollback()
+			},
+			want: db.ErrValidate,
+		},
+		{
+			name:   "cycle",
+			access: people.Access{Role: people.RoleManager, ManagerID: managerId(3)},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectAccess(mock, 3, "manager", int64(4))
+				expectAccess(mock, 4, "manager", int64(2))
+				mock.ExpectRollback()
+			},
+			want: db.ErrValidate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			expectAccess(mock, 1, "admin", nil)
+			mock.ExpectBegin()
+			expectAccess(mock, 2, "manager", nil)
+			tt.expect(mock)
+
+			_, err := s.PutAccess(context.Background(), "1", "2", tt.access)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func expectPutAccess(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("UPDATE people SET role = ").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
@@ -1,41 +1,55 @@
 package service
 
 import (
+	"context"
+	"effectiveMobile/pkg/config"
 	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/repo"
 	peopleI "effectiveMobile/pkg/repo/people/interface"
+	sessionI "effectiveMobile/pkg/repo/session/interface"
 	taskI "effectiveMobile/pkg/repo/task/interface"
-
-	"context"
 	interfaces "effectiveMobile/pkg/service/interface"
+	"effectiveMobile/pkg/tracing"
 	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type service struct {
-	rPeople peopleI.PeopleRepository
-	rTask   taskI.TaskRepository
+	uow        repo.UnitOfWork
+	rPeople    peopleI.PeopleRepository
+	rTask      taskI.TaskRepository
+	rSession   sessionI.SessionRepository
+	bcryptCost int
+	refreshTTL time.Duration
+	// dummyHash is compared against when the passport is unknown
+	dummyHash func() []byte
 }
 
 func NewService(
+	cfg config.Config,
+	uow repo.UnitOfWork,
 	peopleRepository peopleI.PeopleRepository,
 	taskRepository taskI.TaskRepository,
+	sessionRepository sessionI.SessionRepository,
 ) interfaces.ServiceUseCase {
 	return &service{
-		rPeople: peopleRepository,
-		rTask:   taskRepository,
+		uow:        uow,
+		rPeople:    peopleRepository,
+		rTask:      taskRepository,
+		rSession:   sessionRepository,
+		bcryptCost: cfg.BcryptCost,
+		refreshTTL: cfg.RefreshTTL,
+		dummyHash: sync.OnceValue(func() []byte {
+			hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), cfg.BcryptCost)
+			return hash
+		}),
 	}
 }
 
-func (s *service) Migrate(ctx context.Context) error {
-	if err := s.rPeople.Migrate(ctx); err != nil {
-		return err
-	}
-	if err := s.rTask.Migrate(ctx); err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (s *service) checkIdParam(id string) (int64, error) {
 	idInt, err := strconv.ParseInt(id, 10, 64)
 	if err != nil || idInt <= 0 {
@@ -43,3 +57,22 @@ func (s *service) checkIdParam(id string) (int64, error) {
 	}
 	return idInt, nil
 }
+
+// withTx runs fn with a copy of the service whose repositories share one
+// transaction, so multi-step operations commit or roll back together.
+func (s *service) withTx(ctx context.Context, fn func(tx *service) error) error {
+	return s.uow.WithTx(ctx, func(repos repo.Repositories) error {
+		tx := *s
+		tx.rPeople = repos.People
+		tx.rTask = repos.Task
+		tx.rSession = repos.Session
+		return fn(&tx)
+	})
+}
+
+// startSpan opens the span of a service method as a child of the request
+// span, so a slow request shows whether the time went to the service or
+// to its queries.
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "service."+method)
+}
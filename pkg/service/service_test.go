@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/pkg/config"
+	"effectiveMobile/pkg/repo"
+	"effectiveMobile/pkg/repo/people"
+	"effectiveMobile/pkg/repo/session"
+	"effectiveMobile/pkg/repo/task"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestService builds the service with every repository and the unit of
+// work on conn.
+func newTestService(conn *sql.DB) *service {
+	cfg := config.Config{BcryptCost: bcrypt.MinCost, RefreshTTL: time.Hour}
+	return NewService(
+		cfg,
+		repo.NewUnitOfWork(conn),
+		people.NewPeopleDataBase(conn),
+		task.NewTaskDataBase(conn),
+		session.NewSessionDataBase(conn),
+	).(*service)
+}
+
+// newMockService is newTestService over sqlmock. The expectations are
+// checked when the test ends.
+func newMockService(t *testing.T) (*service, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		conn.Close()
+	})
+	return newTestService(conn), mock
+}
+
+// TestWithTxRollback fails the last step of an operation. The steps before
+// it ran in the same transaction, so they are rolled back with it.
+func TestWithTxRollback(t *testing.T) {
+	failure := errors.New("connection reset")
+	start := time.Now().UTC().Add(-time.Hour)
+	// expectRunning answers the lookup of running task 9 of person 1.
+	expectRunning := func(mock sqlmock.Sqlmock, query string) {
+		mock.ExpectQuery(query).
+			WithArgs(int64(9)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "startTime", "endTime", "totalTime", "personId", "status"}).
+				AddRow(int64(9), "task", "", start, nil, nil, int64(1), "running"))
+		mock.ExpectQuery("FROM task_interval WHERE taskId = ANY").
+			WillReturnRows(sqlmock.NewRows([]string{"taskId", "startTime", "endTime"}).AddRow(int64(9), start, nil))
+	}
+
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+		call   func(s *service) error
+	}{
+		{
+			name: "delete person",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM task WHERE personId = ").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec("DELETE FROM people WHERE id = ").
+					WithArgs(int64(1)).
+					WillReturnError(failure)
+			},
+			call: func(s *service) error {
+				return s.DeletePeople(context.Background(), "1", "1")
+			},
+		},
+		{
+			name: "finish, interval",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectRunning(mock, "FROM task WHERE id = \\$1 FOR UPDATE")
+				mock.ExpectExec("UPDATE task_interval SET endTime").
+					WillReturnError(failure)
+			},
+			call: func(s *service) error {
+				_, err := s.TaskFinish(context.Background(), "1", "9")
+				return err
+			},
+		},
+		{
+			name: "finish, total",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectRunning(mock, "FROM task WHERE id = \\$1 FOR UPDATE")
+				mock.ExpectExec("UPDATE task_interval SET endTime").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRunning(mock, "FROM task WHERE id = \\$1$")
+				mock.ExpectExec("UPDATE task SET name").
+					WillReturnError(failure)
+			},
+			call: func(s *service) error {
+				_, err := s.TaskFinish(context.Background(), "1", "9")
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			mock.ExpectBegin()
+			tt.expect(mock)
+			mock.ExpectRollback()
+
+			if err := tt.call(s); !errors.Is(err, failure) {
+				t.Errorf("got %v, want %v", err, failure)
+			}
+		})
+	}
+}
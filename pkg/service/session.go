@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/session"
+	"effectiveMobile/pkg/logger"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+func (s *service) CreateSession(ctx context.Context, personId int64, userAgent string) (*session.Session, string, error) {
+	ctx, span := startSpan(ctx, "CreateSession")
+	defer span.End()
+
+	familyId, err := randomString(16)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.newSession(ctx, personId, familyId, userAgent)
+}
+
+// RefreshSession exchanges a refresh token for a new session of the same
+// family. A token that was already exchanged means it leaked, so the whole
+// family is revoked and the person has to log in again.
+func (s *service) RefreshSession(ctx context.Context, refreshToken string, userAgent string) (*session.Session, string, error) {
+	ctx, span := startSpan(ctx, "RefreshSession")
+	defer span.End()
+
+	if refreshToken == "" {
+		return nil, "", db.ErrParamNotFound
+	}
+	curr, err := s.rSession.GetByRefresh(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, "", err
+	}
+	if curr.RevokedAt != nil {
+		return nil, "", db.ErrSessionRevoked
+	}
+	if curr.RotatedAt != nil {
+		return nil, "", s.revokeFamily(ctx, curr.FamilyID)
+	}
+	if time.Now().UTC().After(curr.ExpiresAt) {
+		return nil, "", db.ErrSessionExpired
+	}
+
+	var result *session.Session
+	var refresh string
+	err = s.withTx(ctx, func(tx *service) error {
+		if err := tx.rSession.Rotate(ctx, curr.ID); err != nil {
+			return err
+		}
+		result, refresh, err = tx.newSession(ctx, curr.PersonID, curr.FamilyID, userAgent)
+		return err
+	})
+	if errors.Is(err, db.ErrUpdateFailed) {
+		// Concurrent refresh with the same token
+		return nil, "", s.revokeFamily(ctx, curr.FamilyID)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return result, refresh, nil
+}
+
+// CheckSession reports whether an access token of the session may be used.
+func (s *service) CheckSession(ctx context.Context, sessionId string) error {
+	ctx, span := startSpan(ctx, "CheckSession")
+	defer span.End()
+
+	curr, err := s.rSession.Get(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	if curr.RevokedAt != nil {
+		return db.ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *service) Logout(ctx context.Context, sessionId string) error {
+	ctx, span := startSpan(ctx, "Logout")
+	defer span.End()
+
+	return s.rSession.Revoke(ctx, sessionId)
+}
+
+func (s *service) GetSessions(ctx context.Context, userId string) ([]session.Session, error) {
+	ctx, span := startSpan(ctx, "GetSessions")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.rSession.GetActive(ctx, userIdInt)
+}
+
+func (s *service) RevokeSession(ctx context.Context, userId string, sessionId string) error {
+	ctx, span := startSpan(ctx, "RevokeSession")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return err
+	}
+	curr, err := s.rSession.Get(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	if curr.PersonID != userIdInt {
+		return db.ErrForbidden
+	}
+	return s.rSession.Revoke(ctx, sessionId)
+}
+
+func (s *service) newSession(ctx context.Context, personId int64, familyId string, userAgent string) (*session.Session, string, error) {
+	id, err := randomString(16)
+	if err != nil {
+		return nil, "", err
+	}
+	refreshToken, err := randomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	access, err := s.rPeople.GetAccess(ctx, personId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	result, err := s.rSession.Post(ctx, session.Session{
+		ID:        id,
+		PersonID:  personId,
+		FamilyID:  familyId,
+		UserAgent: userAgent,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}, hashToken(refreshToken))
+	if err != nil {
+		return nil, "", err
+	}
+	result.Role = access.Role
+
+	return result, refreshToken, nil
+}
+
+func (s *service) revokeFamily(ctx context.Context, familyId string) error {
+	logger.FromContext(ctx).WithField("familyId", familyId).Warn("refresh token reuse, revoking session family")
+	if err := s.rSession.RevokeFamily(ctx, familyId); err != nil {
+		return err
+	}
+	return db.ErrTokenReuse
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken Refresh токены хранятся только в виде хеша
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"effectiveMobile/pkg/db"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const refreshToken = "refresh-token"
+
+// expectByRefresh answers the lookup of refreshToken with session "old" of
+// family "family".
+func expectByRefresh(mock sqlmock.Sqlmock, expiresAt time.Time, rotatedAt, revokedAt driver.Value) {
+	mock.ExpectQuery("FROM session WHERE refreshHash = ").
+		WithArgs(hashToken(refreshToken)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "personId", "familyId", "userAgent", "createdAt", "expiresAt", "rotatedAt", "revokedAt"}).
+			AddRow("old", int64(1), "family", "test", expiresAt.Add(-time.Hour), expiresAt, rotatedAt, revokedAt))
+}
+
+func expectRevokeFamily(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("UPDATE session SET revokedAt = .* WHERE familyId = ").
+		WithArgs("family").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+}
+
+func TestRefreshSession(t *testing.T) {
+	s, mock := newMockService(t)
+	now := time.Now().UTC()
+
+	expectByRefresh(mock, now.Add(time.Hour), nil, nil)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE session SET rotatedAt = .* WHERE id = .* AND rotatedAt IS NULL AND revokedAt IS NULL").
+		WithArgs("old").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, role, managerId FROM people").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "managerId"}).AddRow(int64(1), "manager", nil))
+	mock.ExpectExec("INSERT INTO session").
+		WithArgs(sqlmock.AnyArg(), int64(1), "family", sqlmock.AnyArg(), "agent", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, refresh, err := s.RefreshSession(context.Background(), refreshToken, "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ID == "old" || result.FamilyID != "family" || result.PersonID != 1 || result.Role != "manager" {
+		t.Errorf("got session %+v, want a new session of family \"family\"", result)
+	}
+	if refresh == "" || refresh == refreshToken {
+		t.Errorf("refresh token %q was not renewed", refresh)
+	}
+	if !result.ExpiresAt.After(now) {
+		t.Errorf("new session expires at %v", result.ExpiresAt)
+	}
+}
+
+// TestRefreshSessionRefused covers the refresh tokens that must not give a
+// new session. A rotated token used again, or one whose rotation loses the
+// race against a concurrent refresh, revokes the whole family.
+func TestRefreshSessionRefused(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+		want   error
+	}{
+		{
+			name: "reused",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectByRefresh(mock, now.Add(time.Hour), now.Add(-time.Minute), nil)
+				expectRevokeFamily(mock)
+			},
+			want: db.ErrTokenReuse,
+		},
+		{
+			name: "concurrent rotate",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectByRefresh(mock, now.Add(time.Hour), nil, nil)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE session SET rotatedAt").
+					WithArgs("old").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				expectRevokeFamily(mock)
+			},
+			want: db.ErrTokenReuse,
+		},
+		{
+			name: "revoked",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectByRefresh(mock, now.Add(time.Hour), nil, now.Add(-time.Minute))
+			},
+			want: db.ErrSessionRevoked,
+		},
+		{
+			name: "expired",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectByRefresh(mock, now.Add(-time.Minute), nil, nil)
+			},
+			want: db.ErrSessionExpired,
+		},
+		{
+			name: "unknown",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM session WHERE refreshHash = ").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			want: db.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			tt.expect(mock)
+
+			result, refresh, err := s.RefreshSession(context.Background(), refreshToken, "agent")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if result != nil || refresh != "" {
+				t.Errorf("got session %+v and refresh token %q", result, refresh)
+			}
+		})
+	}
+}
+
+func TestCheckSession(t *testing.T) {
+	now := time.Now().UTC()
+	for _, tt := range []struct {
+		name      string
+		revokedAt driver.Value
+		want      error
+	}{
+		{"active", nil, nil},
+		{"revoked", now, db.ErrSessionRevoked},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			mock.ExpectQuery("FROM session WHERE id = ").
+				WithArgs("session").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "personId", "familyId", "userAgent", "createdAt", "expiresAt", "rotatedAt", "revokedAt"}).
+					AddRow("session", int64(1), "family", "test", now, now.Add(time.Hour), nil, tt.revokedAt))
+
+			if err := s.CheckSession(context.Background(), "session"); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/task"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTransitionRefused moves a task along every pair the state machine
+// forbids. transition refuses them before touching the repository, with
+// db.ErrTaskState, the 409 of the API, and the pair in a TransitionError.
+func TestTransitionRefused(t *testing.T) {
+	statuses := []task.Status{task.StatusPlanned, task.StatusRunning, task.StatusPaused, task.StatusFinished, task.StatusCancelled}
+	s := &service{}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if from.CanBecome(to) {
+				continue
+			}
+			currTask := &task.Task{ID: 42, Status: from}
+			err := s.transition(context.Background(), currTask, to, time.Now())
+			if !errors.Is(err, db.ErrTaskState) {
+				t.Errorf("%s → %s: got %v, want db.ErrTaskState", from, to, err)
+				continue
+			}
+			var transitionErr *task.TransitionError
+			if !errors.As(err, &transitionErr) || transitionErr.TaskID != 42 || transitionErr.From != from || transitionErr.To != to {
+				t.Errorf("%s → %s: got %#v", from, to, transitionErr)
+			}
+			if currTask.Status != from {
+				t.Errorf("%s → %s: task became %s", from, to, currTask.Status)
+			}
+		}
+	}
+}
@@ -2,119 +2,626 @@ package service
 
 import (
 	"context"
+	"effectiveMobile/pkg/db"
 	"effectiveMobile/pkg/domain/task"
+	"effectiveMobile/pkg/metrics"
 	"fmt"
-	"sort"
 	"time"
 )
 
-func (s *service) TaskStart(ctx context.Context, id string, newTask task.Task) (*task.Task, error) {
+// TaskStart creates a task of the person and starts its timer. When the
+// person may run a single timer, onRunning tells what happens to the one
+// already running.
+func (s *service) TaskStart(ctx context.Context, id string, newTask task.Task, onRunning task.OnRunning) (*task.Task, error) {
+	ctx, span := startSpan(ctx, "TaskStart")
+	defer span.End()
+
 	idInt, err := s.checkIdParam(id)
 	if err != nil {
 		return nil, err
 	}
+	if err = checkOnRunning(onRunning); err != nil {
+		return nil, err
+	}
 
 	newTask.StartTime = time.Now().UTC()
-	result, err := s.rTask.Post(ctx, newTask)
+	newTask.PersonID = idInt
+	newTask.Status = task.StatusRunning
+	var result *task.Task
+	var switched int
+	err = s.withTx(ctx, func(tx *service) error {
+		// Задача создается только для существующего человека, LockTimer это проверяет
+		switched, err = tx.claimTimer(ctx, idInt, onRunning, newTask.StartTime)
+		if err != nil {
+			return err
+		}
+		result, err = tx.rTask.Post(ctx, newTask)
+		if err != nil {
+			return err
+		}
+		// Таймер задачи запускается сразу
+		if err = tx.rTask.StartInterval(ctx, result.ID, result.StartTime); err != nil {
+			return err
+		}
+		result.Intervals = []task.Interval{{StartTime: result.StartTime}}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	err = s.rPeople.AppendTask(ctx, idInt, *result)
+	metrics.TasksStarted.Inc()
+	countSwitched(onRunning, switched)
+
+	return result, nil
+}
+
+// TaskPlan creates a planned task of the person without starting its
+// timer. StartTime holds the time it was planned until TaskRun starts it.
+func (s *service) TaskPlan(ctx context.Context, id string, newTask task.Task) (*task.Task, error) {
+	ctx, span := startSpan(ctx, "TaskPlan")
+	defer span.End()
+
+	idInt, err := s.checkIdParam(id)
 	if err != nil {
-		//Можно было сделать транзакцию на уровне бд, но я решил так быстрее
-		e := s.rTask.Delete(ctx, result.ID)
-		if e != nil {
-			return nil, e
-		}
 		return nil, err
 	}
 
+	newTask.StartTime = time.Now().UTC()
+	newTask.PersonID = idInt
+	newTask.Status = task.StatusPlanned
+	var result *task.Task
+	err = s.withTx(ctx, func(tx *service) error {
+		// Задача создается только для существующего человека
+		if _, err := tx.rPeople.GetAccess(ctx, idInt); err != nil {
+			return err
+		}
+		result, err = tx.rTask.Post(ctx, newTask)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
-func (s *service) TaskFinish(ctx context.Context, taskId string) (*task.Task, error) {
+// TaskRun starts the timer of a planned task or resumes a paused one. Under
+// the single timer policy onRunning tells what happens to the task already
+// running.
+func (s *service) TaskRun(ctx context.Context, userId string, taskId string, onRunning task.OnRunning) (*task.Task, error) {
+	ctx, span := startSpan(ctx, "TaskRun")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return nil, err
+	}
 	taskIdInt, err := s.checkIdParam(taskId)
 	if err != nil {
 		return nil, err
 	}
-	currTask, err := s.rTask.Get(ctx, taskIdInt)
+	if err = checkOnRunning(onRunning); err != nil {
+		return nil, err
+	}
+
+	var result *task.Task
+	var from task.Status
+	var switched int
+	err = s.withTx(ctx, func(tx *service) error {
+		// Блокировки в том же порядке, что и в TaskStart: сначала человек, потом задача
+		peek, err := tx.rTask.Get(ctx, taskIdInt)
+		if err != nil {
+			return err
+		}
+		if err = tx.checkTaskAccess(ctx, userIdInt, peek.PersonID); err != nil {
+			return err
+		}
+		if !peek.Status.CanBecome(task.StatusRunning) {
+			return transitionError(peek, task.StatusRunning)
+		}
+		now := time.Now().UTC()
+		switched, err = tx.claimTimer(ctx, peek.PersonID, onRunning, now)
+		if err != nil {
+			return err
+		}
+
+		currTask, err := tx.accessibleTask(ctx, userIdInt, taskIdInt)
+		if err != nil {
+			return err
+		}
+		from = currTask.Status
+		if err = tx.transition(ctx, currTask, task.StatusRunning, now); err != nil {
+			return err
+		}
+		result = currTask
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if from == task.StatusPlanned {
+		metrics.TasksStarted.Inc()
+	} else {
+		metrics.TasksResumed.Inc()
+	}
+	countSwitched(onRunning, switched)
+
+	return result, nil
+}
+
+// TaskFinish stops the timer of the task if it runs and closes the task.
+// TotalTime is the sum of its intervals, pauses are not counted.
+func (s *service) TaskFinish(ctx context.Context, userId string, taskId string) (*task.Task, error) {
+	ctx, span := startSpan(ctx, "TaskFinish")
+	defer span.End()
+
+	result, err := s.changeStatus(ctx, userId, taskId, task.StatusFinished)
 	if err != nil {
 		return nil, err
 	}
-	now := time.Now().UTC()
-	currTask.EndTime = &now
-	duration := now.Sub(currTask.StartTime)
-	currTask.TotalTime = &duration
+	metrics.TasksFinished.Inc()
+
+	return result, nil
+}
 
-	result, err := s.rTask.Put(ctx, taskIdInt, *currTask)
+// TaskPause stops the timer of a running task until TaskRun.
+func (s *service) TaskPause(ctx context.Context, userId string, taskId string) (*task.Task, error) {
+	ctx, span := startSpan(ctx, "TaskPause")
+	defer span.End()
+
+	result, err := s.changeStatus(ctx, userId, taskId, task.StatusPaused)
 	if err != nil {
 		return nil, err
 	}
+	metrics.TasksPaused.Inc()
 
 	return result, nil
 }
 
-func (s *service) TaskPut(ctx context.Context, id string, updateTask task.Task) (*task.Task, error) {
-	idInt, err := s.checkIdParam(id)
+// TaskCancel closes a task that will not be done. The time already worked
+// stays in TotalTime.
+func (s *service) TaskCancel(ctx context.Context, userId string, taskId string) (*task.Task, error) {
+	ctx, span := startSpan(ctx, "TaskCancel")
+	defer span.End()
+
+	return s.changeStatus(ctx, userId, taskId, task.StatusCancelled)
+}
+
+// changeStatus locks a task the caller may work with and moves it to next,
+// which must not be running: that needs the person lock of TaskRun.
+func (s *service) changeStatus(ctx context.Context, userId string, taskId string, next task.Status) (*task.Task, error) {
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return nil, err
+	}
+	taskIdInt, err := s.checkIdParam(taskId)
 	if err != nil {
 		return nil, err
 	}
-	result, err := s.rTask.Put(ctx, idInt, updateTask)
+	var result *task.Task
+	err = s.withTx(ctx, func(tx *service) error {
+		currTask, err := tx.accessibleTask(ctx, userIdInt, taskIdInt)
+		if err != nil {
+			return err
+		}
+		if err = tx.transition(ctx, currTask, next, time.Now().UTC()); err != nil {
+			return err
+		}
+		result = currTask
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (s *service) GetTask(ctx context.Context, startTimeStr string, endTimeStr string) ([]task.Task, error) {
-	startTime, endTime, err := parseTimeStrings(startTimeStr, endTimeStr)
+// transition moves a locked task to next if the state machine allows it:
+// it starts or stops the timer, closes finished and cancelled tasks and
+// stores the task with TotalTime recomputed.
+func (s *service) transition(ctx context.Context, currTask *task.Task, next task.Status, now time.Time) error {
+	if !currTask.Status.CanBecome(next) {
+		return transitionError(currTask, next)
+	}
+
+	switch {
+	case currTask.Status == task.StatusRunning:
+		if err := s.rTask.StopInterval(ctx, currTask.ID, now); err != nil {
+			return err
+		}
+	case next == task.StatusRunning:
+		if currTask.Status == task.StatusPlanned {
+			currTask.StartTime = now
+		}
+		if err := s.rTask.StartInterval(ctx, currTask.ID, now); err != nil {
+			return err
+		}
+	}
+	if next.Closed() {
+		currTask.EndTime = &now
+	}
+	currTask.Status = next
+
+	return s.storeTotal(ctx, currTask)
+}
+
+func transitionError(currTask *task.Task, next task.Status) error {
+	return fmt.Errorf("%w: %w", db.ErrTaskState, &task.TransitionError{TaskID: currTask.ID, From: currTask.Status, To: next})
+}
+
+// claimTimer locks the person and, if they may run a single timer, pauses
+// or finishes their running tasks as onRunning says, or refuses with
+// db.ErrTimerRunning. It returns how many tasks it stopped.
+func (s *service) claimTimer(ctx context.Context, personId int64, onRunning task.OnRunning, now time.Time) (int, error) {
+	singleTimer, err := s.rPeople.LockTimer(ctx, personId)
+	if err != nil || !singleTimer {
+		return 0, err
+	}
+	running, err := s.rTask.GetRunning(ctx, personId)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range running {
+		next := task.StatusPaused
+		switch onRunning {
+		case task.OnRunningPause:
+		case task.OnRunningFinish:
+			next = task.StatusFinished
+		default:
+			return 0, fmt.Errorf("%w: task %d", db.ErrTimerRunning, running[i].ID)
+		}
+		if err = s.transition(ctx, &running[i], next, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(running), nil
+}
+
+// storeTotal rereads the intervals of the task, sets TotalTime to the sum
+// of the closed ones and stores the task.
+func (s *service) storeTotal(ctx context.Context, currTask *task.Task) error {
+	updated, err := s.rTask.Get(ctx, currTask.ID)
+	if err != nil {
+		return err
+	}
+	worked := updated.Worked()
+	currTask.TotalTime = &worked
+	if _, err = s.rTask.Put(ctx, currTask.ID, *currTask); err != nil {
+		return err
+	}
+	currTask.Intervals = updated.Intervals
+	return nil
+}
+
+func checkOnRunning(onRunning task.OnRunning) error {
+	switch onRunning {
+	case "", task.OnRunningReject, task.OnRunningPause, task.OnRunningFinish:
+		return nil
+	}
+	return fmt.Errorf("%w: onRunning must be one of: reject, pause, finish", db.ErrValidate)
+}
+
+// countSwitched counts the tasks claimTimer stopped once the transaction
+// is committed.
+func countSwitched(onRunning task.OnRunning, switched int) {
+	switch onRunning {
+	case task.OnRunningPause:
+		metrics.TasksPaused.Add(float64(switched))
+	case task.OnRunningFinish:
+		metrics.TasksFinished.Add(float64(switched))
+	}
+}
+
+// TaskRecord stores work done without a timer as a finished task with one
+// interval. Under the single timer policy the entry may not overlap other
+// work of the person.
+func (s *service) TaskRecord(ctx context.Context, id string, entry task.Entry) (*task.Task, error) {
+	ctx, span := startSpan(ctx, "TaskRecord")
+	defer span.End()
+
+	idInt, err := s.checkIdParam(id)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime, endTime := entry.StartTime.UTC(), entry.EndTime.UTC()
+	intervals := []task.Interval{{StartTime: startTime, EndTime: &endTime}}
+	if err = task.CheckIntervals(intervals, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("%w: %w", db.ErrValidate, err)
+	}
+
+	var result *task.Task
+	err = s.withTx(ctx, func(tx *service) error {
+		// LockTimer проверяет, что человек существует, и держит его строку до конца транзакции
+		singleTimer, err := tx.rPeople.LockTimer(ctx, idInt)
+		if err != nil {
+			return err
+		}
+		if singleTimer {
+			if err = tx.checkOverlap(ctx, idInt, 0, intervals); err != nil {
+				return err
+			}
+		}
+
+		result, err = tx.rTask.Post(ctx, task.Task{
+			Name:        entry.Name,
+			Description: entry.Description,
+			StartTime:   startTime,
+			EndTime:     &endTime,
+			PersonID:    idInt,
+			Status:      task.StatusFinished,
+		})
+		if err != nil {
+			return err
+		}
+		if err = tx.rTask.PutIntervals(ctx, result.ID, intervals); err != nil {
+			return err
+		}
+		return tx.storeTotal(ctx, result)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return result, nil
+}
 
-	result, err := s.rTask.GetLaborCost(ctx, startTime, endTime)
+// TaskPatch corrects the name, description or times of a task. A new start
+// time moves the start of the first interval, a new end time, allowed for
+// finished and cancelled tasks only, the end of the last one. TotalTime is
+// recomputed from the corrected intervals.
+func (s *service) TaskPatch(ctx context.Context, userId string, taskId string, patch task.Patch) (*task.Task, error) {
+	ctx, span := startSpan(ctx, "TaskPatch")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
 	if err != nil {
 		return nil, err
 	}
+	taskIdInt, err := s.checkIdParam(taskId)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Name != nil && *patch.Name == "" {
+		return nil, fmt.Errorf("%w: %w", db.ErrValidate, &task.FieldError{Field: "name", Message: "must not be empty"})
+	}
+
+	var result *task.Task
+	err = s.withTx(ctx, func(tx *service) error {
+		// Блокировки в том же порядке, что и в TaskStart: сначала человек, потом задача
+		peek, err := tx.rTask.Get(ctx, taskIdInt)
+		if err != nil {
+			return err
+		}
+		if err = tx.checkTaskAccess(ctx, userIdInt, peek.PersonID); err != nil {
+			return err
+		}
+		singleTimer, err := tx.rPeople.LockTimer(ctx, peek.PersonID)
+		if err != nil {
+			return err
+		}
+		currTask, err := tx.accessibleTask(ctx, userIdInt, taskIdInt)
+		if err != nil {
+			return err
+		}
 
-	// Сортировка результатов
-	sort.Sort(result)
+		if patch.Name != nil {
+			currTask.Name = *patch.Name
+		}
+		if patch.Description != nil {
+			currTask.Description = *patch.Description
+		}
+		if patch.StartTime == nil && patch.EndTime == nil {
+			_, err = tx.rTask.Put(ctx, currTask.ID, *currTask)
+			result = currTask
+			return err
+		}
 
+		intervals, err := correctTimes(currTask, patch)
+		if err != nil {
+			return fmt.Errorf("%w: %w", db.ErrValidate, err)
+		}
+		if err = task.CheckIntervals(intervals, time.Now().UTC()); err != nil {
+			return fmt.Errorf("%w: %w", db.ErrValidate, err)
+		}
+		if singleTimer {
+			if err = tx.checkOverlap(ctx, currTask.PersonID, currTask.ID, intervals); err != nil {
+				return err
+			}
+		}
+		if len(intervals) > 0 {
+			if err = tx.rTask.PutIntervals(ctx, currTask.ID, intervals); err != nil {
+				return err
+			}
+		}
+		if err = tx.storeTotal(ctx, currTask); err != nil {
+			return err
+		}
+		result = currTask
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// correctTimes applies the times of the patch to the task and returns its
+// corrected intervals.
+func correctTimes(currTask *task.Task, patch task.Patch) ([]task.Interval, error) {
+	intervals := append([]task.Interval(nil), currTask.Intervals...)
+
+	if patch.StartTime != nil {
+		startTime := patch.StartTime.UTC()
+		currTask.StartTime = startTime
+		if len(intervals) > 0 {
+			intervals[0].StartTime = startTime
+		}
+	}
+	if patch.EndTime != nil {
+		if !currTask.Status.Closed() || len(intervals) == 0 {
+			return nil, &task.FieldError{Field: "endTime", Message: "can be corrected only on a finished or cancelled task that has been worked on"}
+		}
+		endTime := patch.EndTime.UTC()
+		currTask.EndTime = &endTime
+		last := len(intervals) - 1
+		intervals[last].EndTime = &endTime
+	}
+	return intervals, nil
+}
+
+// checkOverlap refuses intervals that overlap work on another task of the
+// person.
+func (s *service) checkOverlap(ctx context.Context, personId int64, taskId int64, intervals []task.Interval) error {
+	now := time.Now().UTC()
+	for _, interval := range intervals {
+		endTime := now
+		if interval.EndTime != nil {
+			endTime = *interval.EndTime
+		}
+		otherId, err := s.rTask.Overlapping(ctx, personId, taskId, interval.StartTime, endTime)
+		if err != nil {
+			return err
+		}
+		if otherId != 0 {
+			return fmt.Errorf("%w: %w", db.ErrTimeOverlap, &task.FieldError{Field: "startTime", Message: fmt.Sprintf("overlaps task %d", otherId)})
+		}
+	}
+	return nil
+}
+
+// GetTask reports the labor cost of a person over a period. An empty
+// personId is the caller.
+func (s *service) GetTask(ctx context.Context, userId string, personId string, startTimeStr string, endTimeStr string, statusStr string) (*task.Report, error) {
+	ctx, span := startSpan(ctx, "GetTask")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return nil, err
+	}
+	personIdInt := userIdInt
+	if personId != "" {
+		if personIdInt, err = s.checkIdParam(personId); err != nil {
+			return nil, err
+		}
+	}
+	startTime, endTime, err := parseTimeStrings(startTimeStr, endTimeStr)
+	if err != nil {
+		return nil, err
+	}
+	if !startTime.Before(endTime) {
+		return nil, fmt.Errorf("%w: %w", db.ErrValidate, &task.FieldError{Field: "startTime", Message: "must be before endTime"})
+	}
+	statuses, err := parseStatuses(statusStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkReportAccess(ctx, userIdInt, personIdInt); err != nil {
+		return nil, err
+	}
+	// Для несуществующего человека 404, а не пустой отчет
+	if _, err = s.rPeople.GetAccess(ctx, personIdInt); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.rTask.GetLaborCost(ctx, personIdInt, startTime, endTime, statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	report := task.NewReport(personIdInt, startTime, endTime, tasks)
+	return &report, nil
+}
+
 func parseTimeStrings(startTimeStr, endTimeStr string) (time.Time, time.Time, error) {
 	layout := "2006-01-02T15:04:05.999999Z"
 
 	startTime, err := time.Parse(layout, startTimeStr)
 	if err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("invalid start time format: %w", err)
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: start time: %s", db.ErrTimeInvalidFormat, err.Error())
 	}
 
 	endTime, err := time.Parse(layout, endTimeStr)
 	if err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("invalid end time format: %w", err)
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: end time: %s", db.ErrTimeInvalidFormat, err.Error())
 	}
 
 	return startTime, endTime, nil
 }
-func (s *service) GetAllTask(ctx context.Context) ([]task.Task, error) {
-	result, err := s.rTask.GetAll(ctx)
+
+func parseStatuses(statusStr string) ([]task.Status, error) {
+	statuses, err := task.ParseStatuses(statusStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", db.ErrValidate, err)
+	}
+	return statuses, nil
+}
+
+func (s *service) GetAllTask(ctx context.Context, userId string, statusStr string) ([]task.Task, error) {
+	ctx, span := startSpan(ctx, "GetAllTask")
+	defer span.End()
+
+	personIds, err := s.readScope(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := parseStatuses(statusStr)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.rTask.GetAll(ctx, personIds, statuses)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (s *service) DeleteTask(ctx context.Context, id string) error {
-	idInt, err := s.checkIdParam(id)
+func (s *service) DeleteTask(ctx context.Context, userId string, taskId string) error {
+	ctx, span := startSpan(ctx, "DeleteTask")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
 	if err != nil {
 		return err
 	}
-
-	err = s.rTask.Delete(ctx, idInt)
+	taskIdInt, err := s.checkIdParam(taskId)
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.withTx(ctx, func(tx *service) error {
+		if _, err := tx.accessibleTask(ctx, userIdInt, taskIdInt); err != nil {
+			return err
+		}
+		return tx.rTask.Delete(ctx, taskIdInt)
+	})
+}
+
+// GetPersonTasks returns the tasks of one person to the person themselves,
+// their manager or an admin.
+func (s *service) GetPersonTasks(ctx context.Context, userId string, personId string, statusStr string) ([]task.Task, error) {
+	ctx, span := startSpan(ctx, "GetPersonTasks")
+	defer span.End()
+
+	userIdInt, err := s.checkIdParam(userId)
+	if err != nil {
+		return nil, err
+	}
+	personIdInt, err := s.checkIdParam(personId)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkTaskAccess(ctx, userIdInt, personIdInt); err != nil {
+		return nil, err
+	}
+	statuses, err := parseStatuses(statusStr)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.rTask.GetAll(ctx, []int64{personIdInt}, statuses)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
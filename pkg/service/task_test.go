@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/domain/task"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectTask answers the lookup of task 9 of person 2 and of its intervals.
+func expectTask(mock sqlmock.Sqlmock, forUpdate bool) {
+	query := "FROM task WHERE id = \\$1$"
+	if forUpdate {
+		query = "FROM task WHERE id = \\$1 FOR UPDATE"
+	}
+	start := time.Now().UTC().Add(-time.Hour)
+	mock.ExpectQuery(query).
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "startTime", "endTime", "totalTime", "personId", "status"}).
+			AddRow(int64(9), "task", "", start, nil, nil, int64(2), "paused"))
+	mock.ExpectQuery("FROM task_interval WHERE taskId = ANY").
+		WillReturnRows(sqlmock.NewRows([]string{"taskId", "startTime", "endTime"}).
+			AddRow(int64(9), start, start.Add(time.Minute)))
+}
+
+// TestForeignTask makes employee 1 work with task 9 of person 2 through
+// every task endpoint. Each refuses before changing anything.
+func TestForeignTask(t *testing.T) {
+	name := "renamed"
+	inTx := func(forUpdate bool) func(mock sqlmock.Sqlmock) {
+		return func(mock sqlmock.Sqlmock) {
+			mock.ExpectBegin()
+			expectTask(mock, forUpdate)
+			expectAccess(mock, 1, "employee", nil)
+			mock.ExpectRollback()
+		}
+	}
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+		call   func(s *service) error
+	}{
+		{"finish", inTx(true), func(s *service) error {
+			_, err := s.TaskFinish(context.Background(), "1", "9")
+			return err
+		}},
+		{"pause", inTx(true), func(s *service) error {
+			_, err := s.TaskPause(context.Background(), "1", "9")
+			return err
+		}},
+		{"cancel", inTx(true), func(s *service) error {
+			_, err := s.TaskCancel(context.Background(), "1", "9")
+			return err
+		}},
+		{"delete", inTx(true), func(s *service) error {
+			return s.DeleteTask(context.Background(), "1", "9")
+		}},
+		{"resume", inTx(false), func(s *service) error {
+			_, err := s.TaskRun(context.Background(), "1", "9", task.OnRunningReject)
+			return err
+		}},
+		{"patch", inTx(false), func(s *service) error {
+			_, err := s.TaskPatch(context.Background(), "1", "9", task.Patch{Name: &name})
+			return err
+		}},
+		{"person tasks", func(mock sqlmock.Sqlmock) {
+			expectAccess(mock, 1, "employee", nil)
+		}, func(s *service) error {
+			_, err := s.GetPersonTasks(context.Background(), "1", "2", "")
+			return err
+		}},
+		{"labor cost", func(mock sqlmock.Sqlmock) {
+			expectAccess(mock, 1, "employee", nil)
+		}, func(s *service) error {
+			_, err := s.GetTask(context.Background(), "1", "2", "2024-07-01T00:00:00Z", "2024-07-02T00:00:00Z", "")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			tt.expect(mock)
+			if err := tt.call(s); !errors.Is(err, db.ErrForbidden) {
+				t.Errorf("got %v, want db.ErrForbidden", err)
+			}
+		})
+	}
+}
+
+// TestMissingTask works with a task that does not exist, which must look
+// the same whoever owns the id.
+func TestMissingTask(t *testing.T) {
+	s, mock := newMockService(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM task WHERE id = ").
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if _, err := s.TaskFinish(context.Background(), "1", "9"); !errors.Is(err, db.ErrNotExist) {
+		t.Errorf("got %v, want db.ErrNotExist", err)
+	}
+}
+
+func TestCorrectTimes(t *testing.T) {
+	start := time.Now().UTC().Add(-2 * time.Hour)
+	end := start.Add(time.Hour)
+	corrected := end.Add(time.Minute)
+	tests := []struct {
+		name      string
+		status    task.Status
+		intervals []task.Interval
+		ok        bool
+	}{
+		{"finished", task.StatusFinished, []task.Interval{{StartTime: start, EndTime: &end}}, true},
+		{"running", task.StatusRunning, []task.Interval{{StartTime: start}}, false},
+		{"cancelled before any work", task.StatusCancelled, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intervals, err := correctTimes(&task.Task{Status: tt.status, Intervals: tt.intervals}, task.Patch{EndTime: &corrected})
+			if !tt.ok {
+				var fieldErr *task.FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != "endTime" {
+					t.Errorf("got %v, want an endTime error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if last := intervals[len(intervals)-1]; last.EndTime == nil || !last.EndTime.Equal(corrected) {
+				t.Errorf("last interval ends at %v, want %v", last.EndTime, corrected)
+			}
+		})
+	}
+}
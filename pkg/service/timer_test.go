@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"effectiveMobile/pkg/db"
+	"effectiveMobile/pkg/db/dbtest"
+	"effectiveMobile/pkg/domain/task"
+	taskRepo "effectiveMobile/pkg/repo/task"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestTaskStartConcurrently starts two timers of one person at the same
+// time with singleTimer on. LockTimer serializes them, so exactly one
+// interval stays open whatever onRunning is: with reject the second start
+// fails with db.ErrTimerRunning (409), with pause or finish it stops the
+// first task.
+func TestTaskStartConcurrently(t *testing.T) {
+	tests := []struct {
+		onRunning task.OnRunning
+		stopped   task.Status
+	}{
+		{task.OnRunningReject, ""},
+		{task.OnRunningPause, task.StatusPaused},
+		{task.OnRunningFinish, task.StatusFinished},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.onRunning), func(t *testing.T) {
+			conn := dbtest.Migrated(t)
+			ctx := context.Background()
+			s := newTestService(conn)
+
+			var personId int64
+			err := conn.QueryRowContext(ctx,
+				"INSERT INTO people(passportNumber, password, singleTimer) VALUES ('1234 567890', 'x', true) RETURNING id",
+			).Scan(&personId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id := strconv.FormatInt(personId, 10)
+
+			start := make(chan struct{})
+			errs := make([]error, 2)
+			var wg sync.WaitGroup
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					_, errs[i] = s.TaskStart(ctx, id, task.Task{Name: "task " + strconv.Itoa(i)}, tt.onRunning)
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+
+			var failed int
+			for _, err := range errs {
+				if err == nil {
+					continue
+				}
+				if !errors.Is(err, db.ErrTimerRunning) {
+					t.Fatalf("TaskStart: %v", err)
+				}
+				failed++
+			}
+
+			var open int
+			err = conn.QueryRowContext(ctx,
+				"SELECT COUNT(*) FROM task_interval i JOIN task t ON t.id = i.taskId WHERE t.personId = $1 AND i.endTime IS NULL",
+				personId).Scan(&open)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if open != 1 {
+				t.Errorf("%d open intervals, want 1", open)
+			}
+
+			tasks, err := taskRepo.NewTaskDataBase(conn).GetAll(ctx, []int64{personId}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			statuses := make(map[task.Status]int)
+			for _, tk := range tasks {
+				statuses[tk.Status]++
+			}
+
+			if tt.onRunning == task.OnRunningReject {
+				if failed != 1 || len(tasks) != 1 || statuses[task.StatusRunning] != 1 {
+					t.Errorf("%d starts failed, tasks %v, want one rejected start and one running task", failed, statuses)
+				}
+				return
+			}
+			if failed != 0 || len(tasks) != 2 || statuses[task.StatusRunning] != 1 || statuses[tt.stopped] != 1 {
+				t.Errorf("%d starts failed, tasks %v, want one running and one %s task", failed, statuses, tt.stopped)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package token
+
+import (
+	"crypto"
+	"effectiveMobile/pkg/config"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrNoKeys     = errors.New("no jwt keys configured")
+	ErrUnknownKid = errors.New("unknown jwt key id")
+	ErrClaims     = errors.New("invalid jwt claims")
+)
+
+// Claims represents the payload of an access token.
+type Claims struct {
+	ID   string `json:"id"`
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// SessionID returns the jti claim, the id of the session the token belongs to.
+func (c *Claims) SessionID() string {
+	return c.RegisteredClaims.ID
+}
+
+// Manager issues and verifies access tokens. Every configured key verifies
+// tokens, only the signing key issues new ones, so keys can be rotated by
+// adding a new kid, switching JWT_SIGNING_KID and dropping the old kid once
+// its tokens have expired.
+type Manager struct {
+	method     jwt.SigningMethod
+	signingKid string
+	signingKey interface{}
+	verifyKeys map[string]interface{}
+	issuer     string
+	audience   string
+	ttl        time.Duration
+}
+
+func NewManager(cfg config.Config) (*Manager, error) {
+	method := jwt.GetSigningMethod(cfg.JWTAlgorithm)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", cfg.JWTAlgorithm)
+	}
+
+	m := &Manager{
+		method:     method,
+		signingKid: cfg.JWTSigningKid,
+		verifyKeys: make(map[string]interface{}),
+		issuer:     cfg.JWTIssuer,
+		audience:   cfg.JWTAudience,
+		ttl:        cfg.JWTTTL,
+	}
+
+	for _, pair := range strings.Split(cfg.JWTKeys, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kid, value, ok := strings.Cut(pair, ":")
+		if !ok || kid == "" || value == "" {
+			return nil, fmt.Errorf("invalid jwt key %q, expected kid:value", kid)
+		}
+		if m.signingKid == "" {
+			m.signingKid = kid
+		}
+
+		signKey, verifyKey, err := loadKey(method, value)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kid, err)
+		}
+		m.verifyKeys[kid] = verifyKey
+		if kid == m.signingKid {
+			m.signingKey = signKey
+		}
+	}
+
+	if len(m.verifyKeys) == 0 {
+		return nil, ErrNoKeys
+	}
+	if _, ok := m.verifyKeys[m.signingKid]; !ok {
+		return nil, fmt.Errorf("signing key %q: %w", m.signingKid, ErrUnknownKid)
+	}
+	if m.signingKey == nil {
+		return nil, fmt.Errorf("signing key %q holds no private key", m.signingKid)
+	}
+
+	return m, nil
+}
+
+// TTL returns the lifetime of issued tokens.
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Create issues a token of the session for the person id and role and
+// returns it with its expiry.
+func (m *Manager) Create(id string, sessionId string, role string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+
+	claims := Claims{
+		ID:   id,
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionId,
+			Issuer:    m.issuer,
+			Subject:   id,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+
+	t := jwt.NewWithClaims(m.method, claims)
+	t.Header["kid"] = m.signingKid
+
+	signed, err := t.SignedString(m.signingKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Parse verifies the token signature, lifetime, issuer and audience.
+func (m *Manager) Parse(tokenString string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := m.verifyKeys[kid]
+		if !ok {
+			return nil, ErrUnknownKid
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{m.method.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	if m.issuer != "" && !claims.VerifyIssuer(m.issuer, true) {
+		return nil, ErrClaims
+	}
+	if m.audience != "" && !claims.VerifyAudience(m.audience, true) {
+		return nil, ErrClaims
+	}
+	if claims.ID == "" || claims.SessionID() == "" {
+		return nil, ErrClaims
+	}
+
+	return &claims, nil
+}
+
+// loadKey returns the signing and verification keys for the algorithm.
+// For asymmetric algorithms value is a path to a PEM file; a public key
+// only verifies tokens, a private key both signs and verifies.
+func loadKey(method jwt.SigningMethod, value string) (interface{}, interface{}, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return []byte(value), []byte(value), nil
+	case *jwt.SigningMethodRSA:
+		pem, err := os.ReadFile(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+			return private, &private.PublicKey, nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, public, nil
+	case *jwt.SigningMethodEd25519:
+		pem, err := os.ReadFile(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(pem); err == nil {
+			return private, private.(crypto.Signer).Public(), nil
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, public, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported jwt algorithm %q", method.Alg())
+	}
+}
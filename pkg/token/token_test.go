@@ -0,0 +1,211 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"effectiveMobile/pkg/config"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	secret      = "0123456789abcdef0123456789abcdef"
+	otherSecret = "fedcba9876543210fedcba9876543210"
+)
+
+// writePEM writes the block to a file of the test and returns its path.
+func writePEM(t *testing.T, name string, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rsaKeys returns the paths of a new RSA private key and of its public key.
+func rsaKeys(t *testing.T) (string, string) {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private)),
+		writePEM(t, "rsa.pub", "PUBLIC KEY", public)
+}
+
+// edKey returns the path of a new Ed25519 private key.
+func edKey(t *testing.T) string {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "ed25519.pem", "PRIVATE KEY", der)
+}
+
+func newManager(t *testing.T, cfg config.Config) *Manager {
+	t.Helper()
+	if cfg.JWTTTL == 0 {
+		cfg.JWTTTL = time.Minute
+	}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func create(t *testing.T, m *Manager) string {
+	t.Helper()
+	signed, _, err := m.Create("1", "session", "employee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestCreateParse(t *testing.T) {
+	rsaPrivate, _ := rsaKeys(t)
+	tests := []struct {
+		algorithm string
+		keys      string
+	}{
+		{"HS256", "a:" + secret},
+		{"HS512", "a:" + secret + secret},
+		{"RS256", "a:" + rsaPrivate},
+		{"EdDSA", "a:" + edKey(t)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			m := newManager(t, config.Config{JWTAlgorithm: tt.algorithm, JWTKeys: tt.keys, JWTIssuer: "people", JWTAudience: "api"})
+			claims, err := m.Parse(create(t, m))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.ID != "1" || claims.SessionID() != "session" || claims.Role != "employee" {
+				t.Errorf("got %+v", claims)
+			}
+		})
+	}
+}
+
+// TestRotation walks through a key rotation: the new key is added, then
+// signs, then the old key is dropped.
+func TestRotation(t *testing.T) {
+	before := newManager(t, config.Config{JWTAlgorithm: "HS256", JWTKeys: "old:" + secret})
+	during := newManager(t, config.Config{JWTAlgorithm: "HS256", JWTKeys: "old:" + secret + ",new:" + otherSecret, JWTSigningKid: "new"})
+	after := newManager(t, config.Config{JWTAlgorithm: "HS256", JWTKeys: "new:" + otherSecret})
+
+	oldToken := create(t, before)
+	newToken := create(t, during)
+	if _, err := during.Parse(oldToken); err != nil {
+		t.Errorf("token of the previous key during rotation: %v", err)
+	}
+	if _, err := after.Parse(newToken); err != nil {
+		t.Errorf("token of the new key after rotation: %v", err)
+	}
+	if _, err := after.Parse(oldToken); !errors.Is(err, ErrUnknownKid) {
+		t.Errorf("token of the retired key: got %v, want ErrUnknownKid", err)
+	}
+}
+
+// TestRotationPublicKey verifies with the public key of the previous RSA
+// key, which can no longer sign.
+func TestRotationPublicKey(t *testing.T) {
+	oldPrivate, oldPublic := rsaKeys(t)
+	newPrivate, _ := rsaKeys(t)
+	before := newManager(t, config.Config{JWTAlgorithm: "RS256", JWTKeys: "old:" + oldPrivate})
+	during := newManager(t, config.Config{JWTAlgorithm: "RS256", JWTKeys: "old:" + oldPublic + ",new:" + newPrivate, JWTSigningKid: "new"})
+
+	if _, err := during.Parse(create(t, before)); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewManager(config.Config{JWTAlgorithm: "RS256", JWTKeys: "old:" + oldPublic}); err == nil {
+		t.Error("a public key signs tokens")
+	}
+}
+
+func TestParseRefused(t *testing.T) {
+	rsaPrivate, rsaPublic := rsaKeys(t)
+	cfg := config.Config{JWTAlgorithm: "HS256", JWTKeys: "a:" + secret, JWTIssuer: "people", JWTAudience: "api"}
+	m := newManager(t, cfg)
+	rs := newManager(t, config.Config{JWTAlgorithm: "RS256", JWTKeys: "a:" + rsaPrivate, JWTIssuer: "people", JWTAudience: "api"})
+	publicPEM, err := os.ReadFile(rsaPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sign issues a token of the claims with an arbitrary method and key.
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, claims Claims) string {
+		signed := jwt.NewWithClaims(method, claims)
+		signed.Header["kid"] = kid
+		result, err := signed.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	claims := func(change func(c *Claims)) Claims {
+		c := Claims{ID: "1", Role: "employee", RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "session",
+			Issuer:    "people",
+			Audience:  jwt.ClaimStrings{"api"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}}
+		change(&c)
+		return c
+	}
+	valid := func(*Claims) {}
+	otherIssuer := cfg
+	otherIssuer.JWTIssuer = "billing"
+	otherAudience := cfg
+	otherAudience.JWTAudience = "admin"
+	expired := cfg
+	expired.JWTTTL = -time.Minute
+
+	tests := []struct {
+		name  string
+		m     *Manager
+		token string
+		want  error
+	}{
+		{"retired kid", m, sign(jwt.SigningMethodHS256, "b", []byte(secret), claims(valid)), ErrUnknownKid},
+		{"no kid", m, sign(jwt.SigningMethodHS256, "", []byte(secret), claims(valid)), ErrUnknownKid},
+		{"wrong alg", m, sign(jwt.SigningMethodHS384, "a", []byte(secret), claims(valid)), nil},
+		{"public key as secret", rs, sign(jwt.SigningMethodHS256, "a", publicPEM, claims(valid)), nil},
+		{"alg none", m, sign(jwt.SigningMethodNone, "a", jwt.UnsafeAllowNoneSignatureType, claims(valid)), nil},
+		{"wrong iss", m, create(t, newManager(t, otherIssuer)), ErrClaims},
+		{"wrong aud", m, create(t, newManager(t, otherAudience)), ErrClaims},
+		{"no aud", m, sign(jwt.SigningMethodHS256, "a", []byte(secret), claims(func(c *Claims) { c.Audience = nil })), ErrClaims},
+		{"no session", m, sign(jwt.SigningMethodHS256, "a", []byte(secret), claims(func(c *Claims) { c.RegisteredClaims.ID = "" })), ErrClaims},
+		{"expired", m, create(t, newManager(t, expired)), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.m.Parse(tt.token)
+			if err == nil {
+				t.Fatal("token accepted")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
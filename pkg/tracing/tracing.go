@@ -0,0 +1,99 @@
+// Package tracing sets up OpenTelemetry tracing of the service.
+package tracing
+
+import (
+	"context"
+	"effectiveMobile/pkg/config"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instrumentation name of the spans created by the service itself.
+const name = "effectiveMobile"
+
+// Exporters accepted by TRACING_EXPORTER.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Provider owns the tracer provider and flushes it on Close.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+}
+
+// Setup installs the global tracer provider with the configured exporter
+// and the W3C trace context propagator. With the none exporter spans are
+// still created, so trace ids reach logs, but never leave the process.
+func Setup(ctx context.Context, cfg config.Config) (*Provider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.TracingExporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		// Адрес коллектора берется из OTEL_EXPORTER_OTLP_ENDPOINT
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.TracingExporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewProvider(cfg.TracingServiceName, exporter), nil
+}
+
+// NewProvider installs a global tracer provider that sends spans to the
+// exporter, or drops them when exporter is nil. Tests pass an in-memory
+// exporter to assert on spans.
+func NewProvider(serviceName string, exporter sdktrace.SpanExporter) *Provider {
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+		)),
+	}
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(options...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return &Provider{provider: provider}
+}
+
+// Close exports the buffered spans and stops the provider.
+func (p *Provider) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.provider.Shutdown(ctx)
+}
+
+// ForceFlush exports the buffered spans right away, tests call it before
+// reading the in-memory exporter.
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	return p.provider.ForceFlush(ctx)
+}
+
+// Tracer returns the tracer of the service. It resolves the global provider
+// on every call, so spans go to the provider installed last.
+func Tracer() trace.Tracer {
+	return otel.Tracer(name)
+}
@@ -0,0 +1,10 @@
+// Package version holds build information injected by the linker:
+//
+//	go build -ldflags "-X effectiveMobile/pkg/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X effectiveMobile/pkg/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/app
+package version
+
+var (
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)